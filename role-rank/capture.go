@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Folder untuk menyimpan embed Kotoba yang diterima bot, bahan fixture
+// testdata/kotoba. Kosong = tidak disimpan.
+var kotobaCaptureDir string
+
+var (
+	captureSnowflakeRe = regexp.MustCompile(`\d{17,20}`)
+	captureReportRe    = regexp.MustCompile(`game_reports/[0-9a-fA-F]+`)
+)
+
+// captureKotobaEmbed menulis embed Kotoba apa adanya ke kotobaCaptureDir,
+// dengan ID user dan ID laporan diganti ID tes seperti di
+// testdata/kotoba/README.md. kind dipakai sebagai awalan nama file.
+func captureKotobaEmbed(kind string, embed *discordgo.MessageEmbed) {
+	if kotobaCaptureDir == "" {
+		return
+	}
+
+	data, err := json.MarshalIndent(embed, "", "  ")
+	if err != nil {
+		log.Printf("Gagal menyimpan embed Kotoba: %v", err)
+		return
+	}
+	data = scrubCapture(data)
+
+	name := fmt.Sprintf("%s-%s.json", kind, time.Now().UTC().Format("20060102-150405.000000000"))
+	if err := os.MkdirAll(kotobaCaptureDir, 0o755); err != nil {
+		log.Printf("Gagal membuat folder capture Kotoba: %v", err)
+		return
+	}
+	if err := os.WriteFile(filepath.Join(kotobaCaptureDir, name), append(data, '\n'), 0o644); err != nil {
+		log.Printf("Gagal menyimpan embed Kotoba: %v", err)
+	}
+}
+
+// scrubCapture mengganti setiap snowflake dengan ID tes berurutan (ID yang
+// sama tetap sama) dan ID laporan game dengan ID nol.
+func scrubCapture(data []byte) []byte {
+	ids := make(map[string]string)
+	data = captureSnowflakeRe.ReplaceAllFunc(data, func(id []byte) []byte {
+		if _, ok := ids[string(id)]; !ok {
+			ids[string(id)] = fmt.Sprintf("1%017d", len(ids)+1)
+		}
+		return []byte(ids[string(id)])
+	})
+	return captureReportRe.ReplaceAll(data, []byte("game_reports/000000000000000000000001"))
+}
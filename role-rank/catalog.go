@@ -0,0 +1,184 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync/atomic"
+)
+
+// Batas opsi select menu Discord
+const maxSelectorOptions = 25
+
+// QuizCatalog adalah snapshot katalog quiz yang sedang aktif. Snapshot
+// tidak pernah diubah setelah dibuat; reload menukar pointer-nya.
+type QuizCatalog struct {
+	Quizzes map[string]QuizInfo
+	Order   []string // urutan value quiz berdasarkan level
+}
+
+var (
+	activeCatalog   atomic.Pointer[QuizCatalog]
+	quizCatalogPath = "quizzes.json"
+)
+
+// CurrentCatalog mengembalikan katalog quiz yang sedang aktif.
+func CurrentCatalog() *QuizCatalog {
+	return activeCatalog.Load()
+}
+
+// ReloadQuizCatalog memuat ulang katalog dan konfigurasi guild dari disk
+// lalu menukarnya. Konfigurasi guild divalidasi terhadap katalog baru;
+// jika salah satu file tidak valid, keduanya tetap memakai versi lama.
+func ReloadQuizCatalog() (*QuizCatalog, error) {
+	c, err := LoadQuizCatalog(quizCatalogPath)
+	if err != nil {
+		return nil, err
+	}
+	guilds, err := LoadGuildConfigs(guildConfigPath, c)
+	if err != nil {
+		return nil, err
+	}
+	activeCatalog.Store(c)
+	activeGuilds.Store(&guilds)
+	return c, nil
+}
+
+// catalogFile adalah bentuk file katalog quiz di disk.
+type catalogFile struct {
+	Quizzes []QuizInfo `json:"quizzes"`
+}
+
+// LoadQuizCatalog membaca katalog quiz dari file JSON, memvalidasinya,
+// dan mengembalikan katalog beserta urutan level untuk selector.
+func LoadQuizCatalog(path string) (*QuizCatalog, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("gagal membaca katalog quiz %s: %w", path, err)
+	}
+	defer f.Close()
+
+	dec := json.NewDecoder(f)
+	dec.DisallowUnknownFields()
+
+	var file catalogFile
+	if err := dec.Decode(&file); err != nil {
+		return nil, fmt.Errorf("katalog quiz %s tidak valid: %w", path, err)
+	}
+
+	if err := validateCatalog(file.Quizzes); err != nil {
+		return nil, fmt.Errorf("katalog quiz %s tidak valid:\n%w", path, err)
+	}
+
+	quizzes := make(map[string]QuizInfo, len(file.Quizzes))
+	for _, q := range file.Quizzes {
+		quizzes[q.Value] = q
+	}
+
+	return &QuizCatalog{Quizzes: quizzes, Order: quizOrderFrom(quizzes)}, nil
+}
+
+// validateCatalog mengumpulkan semua kesalahan katalog sekaligus supaya
+// admin bisa memperbaiki file dalam satu kali jalan.
+func validateCatalog(quizzes []QuizInfo) error {
+	var errs []error
+	if len(quizzes) == 0 {
+		return errors.New("katalog tidak berisi quiz apa pun")
+	}
+	if len(quizzes) > maxSelectorOptions {
+		errs = append(errs, fmt.Errorf("maksimal %d quiz (batas select menu Discord), ditemukan %d", maxSelectorOptions, len(quizzes)))
+	}
+
+	values := make(map[string]int)
+	levels := make(map[int]int)
+	roles := make(map[string]int)
+
+	for idx, q := range quizzes {
+		name := fmt.Sprintf("#%d", idx)
+		if q.Value != "" {
+			name += fmt.Sprintf(" (%s)", q.Value)
+		}
+		fail := func(format string, args ...any) {
+			errs = append(errs, fmt.Errorf("quiz %s: "+format, append([]any{name}, args...)...))
+		}
+
+		if q.Value == "" {
+			fail("value wajib diisi")
+		} else if prev, dup := values[q.Value]; dup {
+			fail("value duplikat dengan quiz #%d", prev)
+		} else {
+			values[q.Value] = idx
+		}
+
+		if q.Label == "" {
+			fail("label wajib diisi")
+		}
+
+		if q.Level < 0 {
+			fail("level tidak boleh negatif (%d)", q.Level)
+		} else if prev, dup := levels[q.Level]; dup {
+			fail("level %d duplikat dengan quiz #%d", q.Level, prev)
+		} else {
+			levels[q.Level] = idx
+		}
+
+		if q.RoleID == "" {
+			fail("roleId wajib diisi")
+		} else if prev, dup := roles[q.RoleID]; dup {
+			fail("roleId %s duplikat dengan quiz #%d", q.RoleID, prev)
+		} else {
+			roles[q.RoleID] = idx
+		}
+
+		if q.Cooldown.Duration < 0 {
+			fail("cooldown %s tidak boleh negatif", q.Cooldown)
+		}
+
+		if len(q.Stages) == 0 {
+			fail("minimal satu tahap")
+		}
+		for n, st := range q.Stages {
+			if err := st.Validate(); err != nil {
+				fail("stages[%d]: %v", n, err)
+			}
+		}
+	}
+
+	// Prasyarat dicek setelah semua quiz terbaca supaya urutan di file
+	// tidak berpengaruh
+	levelOf := make(map[string]int, len(quizzes))
+	for _, q := range quizzes {
+		levelOf[q.Value] = q.Level
+	}
+	for _, q := range quizzes {
+		if q.Requires == nil {
+			continue
+		}
+		if req := q.Requires.Quiz; req != "" {
+			if level, ok := levelOf[req]; !ok {
+				errs = append(errs, fmt.Errorf("quiz %s: requires.quiz %s tidak ada di katalog", q.Value, req))
+			} else if level >= q.Level {
+				errs = append(errs, fmt.Errorf("quiz %s: requires.quiz %s harus level lebih rendah", q.Value, req))
+			}
+		}
+		if minLevel := q.Requires.MinLevel; minLevel != nil && (*minLevel < 0 || *minLevel >= q.Level) {
+			errs = append(errs, fmt.Errorf("quiz %s: requires.minLevel %d harus antara 0 dan %d", q.Value, *minLevel, q.Level-1))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// quizOrderFrom mengurutkan quiz berdasarkan level untuk select menu.
+func quizOrderFrom(quizzes map[string]QuizInfo) []string {
+	order := make([]string, 0, len(quizzes))
+	for key := range quizzes {
+		order = append(order, key)
+	}
+	sort.Slice(order, func(a, b int) bool {
+		return quizzes[order[a]].Level < quizzes[order[b]].Level
+	})
+	return order
+}
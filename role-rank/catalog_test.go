@@ -0,0 +1,140 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// catalogQuizzes memuat quizzes.json sebagai daftar, urut level.
+func catalogQuizzes(t *testing.T) []QuizInfo {
+	t.Helper()
+	catalog, err := LoadQuizCatalog("quizzes.json")
+	if err != nil {
+		t.Fatalf("LoadQuizCatalog: %v", err)
+	}
+	list := make([]QuizInfo, 0, len(catalog.Order))
+	for _, key := range catalog.Order {
+		list = append(list, catalog.Quizzes[key])
+	}
+	return list
+}
+
+func TestValidateCatalog(t *testing.T) {
+	intPtr := func(n int) *int { return &n }
+	tests := []struct {
+		name    string
+		mutate  func(quizzes []QuizInfo) []QuizInfo
+		wantErr string // kosong = valid
+	}{
+		{
+			name:   "shipped catalog",
+			mutate: func(q []QuizInfo) []QuizInfo { return q },
+		},
+		{
+			name:    "empty catalog",
+			mutate:  func([]QuizInfo) []QuizInfo { return nil },
+			wantErr: "tidak berisi quiz",
+		},
+		{
+			name:    "duplicate level",
+			mutate:  func(q []QuizInfo) []QuizInfo { q[2].Level = q[1].Level; return q },
+			wantErr: "duplikat dengan quiz #1",
+		},
+		{
+			name:    "duplicate role",
+			mutate:  func(q []QuizInfo) []QuizInfo { q[2].RoleID = q[0].RoleID; return q },
+			wantErr: "duplikat dengan quiz #0",
+		},
+		{
+			name:    "duplicate value",
+			mutate:  func(q []QuizInfo) []QuizInfo { q[3].Value = q[1].Value; return q },
+			wantErr: "value duplikat dengan quiz #1",
+		},
+		{
+			name:    "missing value",
+			mutate:  func(q []QuizInfo) []QuizInfo { q[0].Value = ""; return q },
+			wantErr: "value wajib diisi",
+		},
+		{
+			name:    "negative cooldown",
+			mutate:  func(q []QuizInfo) []QuizInfo { q[0].Cooldown.Duration = -1; return q },
+			wantErr: "tidak boleh negatif",
+		},
+		{
+			name:    "no stages",
+			mutate:  func(q []QuizInfo) []QuizInfo { q[1].Stages = nil; return q },
+			wantErr: "minimal satu tahap",
+		},
+		{
+			name: "stage without decks",
+			mutate: func(q []QuizInfo) []QuizInfo {
+				q[1].Stages = []Stage{{Command: KotobaCommand{ScoreLimit: 10}}}
+				return q
+			},
+			wantErr: "stages[0]",
+		},
+		{
+			name: "stage with negative time limit",
+			mutate: func(q []QuizInfo) []QuizInfo {
+				q[1].Stages = []Stage{q[1].Stages[0]}
+				q[1].Stages[0].TimeLimit.Duration = -1
+				return q
+			},
+			wantErr: "timeLimit",
+		},
+		{
+			name: "requires unknown quiz",
+			mutate: func(q []QuizInfo) []QuizInfo {
+				q[2].Requires = &Prerequisite{Quiz: "Level_99"}
+				return q
+			},
+			wantErr: "requires.quiz Level_99 tidak ada",
+		},
+		{
+			name: "requires higher level",
+			mutate: func(q []QuizInfo) []QuizInfo {
+				q[1].Requires = &Prerequisite{Quiz: q[2].Value}
+				return q
+			},
+			wantErr: "harus level lebih rendah",
+		},
+		{
+			name: "requires minLevel out of range",
+			mutate: func(q []QuizInfo) []QuizInfo {
+				q[1].Requires = &Prerequisite{MinLevel: intPtr(q[1].Level)}
+				return q
+			},
+			wantErr: "requires.minLevel",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateCatalog(tt.mutate(catalogQuizzes(t)))
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("validateCatalog: %v", err)
+			case tt.wantErr != "" && err == nil:
+				t.Fatalf("validateCatalog accepted the catalog, want error containing %q", tt.wantErr)
+			case tt.wantErr != "" && !strings.Contains(err.Error(), tt.wantErr):
+				t.Errorf("error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestValidateCatalogReportsAll memastikan semua kesalahan dilaporkan
+// sekaligus.
+func TestValidateCatalogReportsAll(t *testing.T) {
+	quizzes := catalogQuizzes(t)
+	quizzes[0].Label = ""
+	quizzes[1].Stages = nil
+	err := validateCatalog(quizzes)
+	if err == nil {
+		t.Fatal("validateCatalog accepted the catalog")
+	}
+	for _, want := range []string{"label wajib diisi", "minimal satu tahap"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error = %v, want it to contain %q", err, want)
+		}
+	}
+}
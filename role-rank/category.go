@@ -0,0 +1,219 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Batas channel dalam satu kategori Discord
+const maxCategoryChannels = 50
+
+var errQuizCategoriesFull = errors.New("semua kategori quiz penuh")
+
+var overflowCategories = NewOverflowCategoryStore("overflow_categories.json")
+
+// categoryMu menyatukan pemilihan kategori dan pembuatan channel supaya
+// dua klik bersamaan tidak mengisi slot terakhir yang sama.
+var categoryMu sync.Mutex
+
+// OverflowCategoryStore menyimpan ID kategori overflow yang dibuat bot,
+// per guild. Hanya kategori ini yang dianggap overflow; kategori lain
+// tidak pernah disentuh walaupun namanya mirip.
+type OverflowCategoryStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+func NewOverflowCategoryStore(path string) *OverflowCategoryStore {
+	return &OverflowCategoryStore{path: path}
+}
+
+// IDs mengembalikan kategori overflow yang dibuat bot di guild.
+func (o *OverflowCategoryStore) IDs(guildID string) []string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	categories, err := o.read()
+	if err != nil {
+		log.Printf("Gagal memuat kategori overflow: %v", err)
+		return nil
+	}
+	return categories[guildID]
+}
+
+func (o *OverflowCategoryStore) Add(guildID, categoryID string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	categories, err := o.read()
+	if err != nil {
+		return err
+	}
+	if slices.Contains(categories[guildID], categoryID) {
+		return nil
+	}
+	categories[guildID] = append(categories[guildID], categoryID)
+	return writeJSONFile(o.path, categories)
+}
+
+func (o *OverflowCategoryStore) Remove(guildID, categoryID string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	categories, err := o.read()
+	if err != nil {
+		return err
+	}
+	ids := slices.DeleteFunc(categories[guildID], func(id string) bool { return id == categoryID })
+	if len(ids) == 0 {
+		delete(categories, guildID)
+	} else {
+		categories[guildID] = ids
+	}
+	return writeJSONFile(o.path, categories)
+}
+
+func (o *OverflowCategoryStore) read() (map[string][]string, error) {
+	categories := make(map[string][]string)
+
+	data, err := os.ReadFile(o.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return categories, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("gagal membaca %s: %w", o.path, err)
+	}
+	if len(data) == 0 {
+		return categories, nil
+	}
+	if err := json.Unmarshal(data, &categories); err != nil {
+		return nil, fmt.Errorf("file kategori overflow %s rusak: %w", o.path, err)
+	}
+	return categories, nil
+}
+
+// quizCategorySet mengembalikan semua kategori quiz guild: yang
+// dikonfigurasi dan, kalau overflow otomatis aktif, kategori overflow yang
+// dibuat bot.
+func quizCategorySet(cfg *GuildConfig, guildID string) map[string]bool {
+	set := make(map[string]bool)
+	for _, id := range cfg.Categories() {
+		set[id] = true
+	}
+	if cfg.AutoOverflowCategories {
+		for _, id := range overflowCategories.IDs(guildID) {
+			set[id] = true
+		}
+	}
+	return set
+}
+
+// isQuizCategory cek apakah parentID adalah salah satu kategori quiz.
+func isQuizCategory(cfg *GuildConfig, guildID, parentID string) bool {
+	return parentID != "" && quizCategorySet(cfg, guildID)[parentID]
+}
+
+// pickQuizCategory memilih kategori quiz yang masih punya slot, membuat
+// kategori overflow baru kalau perlu. Dipanggil dengan categoryMu terkunci.
+func pickQuizCategory(s Discord, cfg *GuildConfig, guildID string) (string, error) {
+	channels, err := s.GuildChannels(guildID)
+	if err != nil {
+		return "", err
+	}
+
+	set := quizCategorySet(cfg, guildID)
+	used := make(map[string]int)
+	for _, ch := range channels {
+		if set[ch.ParentID] {
+			used[ch.ParentID]++
+		}
+	}
+
+	// Kategori yang dikonfigurasi dulu, lalu overflow sesuai posisinya
+	candidates := cfg.Categories()
+	var overflow []*discordgo.Channel
+	for _, ch := range channels {
+		if ch.Type == discordgo.ChannelTypeGuildCategory && set[ch.ID] && !slices.Contains(candidates, ch.ID) {
+			overflow = append(overflow, ch)
+		}
+	}
+	slices.SortFunc(overflow, func(a, b *discordgo.Channel) int { return a.Position - b.Position })
+	for _, ch := range overflow {
+		candidates = append(candidates, ch.ID)
+	}
+	for _, id := range candidates {
+		if used[id] < maxCategoryChannels {
+			return id, nil
+		}
+	}
+
+	if !cfg.AutoOverflowCategories {
+		return "", errQuizCategoriesFull
+	}
+	category, err := s.GuildChannelCreateComplex(guildID, discordgo.GuildChannelCreateData{
+		Name: fmt.Sprintf("%s %d", cfg.OverflowName(), len(candidates)+1),
+		Type: discordgo.ChannelTypeGuildCategory,
+		PermissionOverwrites: []*discordgo.PermissionOverwrite{
+			{
+				ID:   guildID, // semua user
+				Type: discordgo.PermissionOverwriteTypeRole,
+				Deny: discordgo.PermissionViewChannel,
+			},
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("gagal membuat kategori overflow: %w", err)
+	}
+	if err := overflowCategories.Add(guildID, category.ID); err != nil {
+		log.Printf("Gagal menyimpan kategori overflow %s: %v", category.ID, err)
+	}
+	return category.ID, nil
+}
+
+// removeEmptyOverflowCategories menghapus kategori overflow yang sudah
+// kosong. Kategori yang dikonfigurasi tidak pernah dihapus.
+func removeEmptyOverflowCategories(s Discord, cfg *GuildConfig, guildID string) {
+	if !cfg.AutoOverflowCategories {
+		return
+	}
+
+	// Daftar channel diambil ulang dengan categoryMu terkunci supaya
+	// kategori yang baru dibuat untuk channel quiz tidak ikut terhapus
+	categoryMu.Lock()
+	defer categoryMu.Unlock()
+
+	channels, err := s.GuildChannels(guildID)
+	if err != nil {
+		log.Printf("Gagal mengambil channel guild %s: %v", guildID, err)
+		return
+	}
+	existing := make(map[string]bool)
+	used := make(map[string]bool)
+	for _, ch := range channels {
+		existing[ch.ID] = true
+		used[ch.ParentID] = true
+	}
+	for _, id := range overflowCategories.IDs(guildID) {
+		if slices.Contains(cfg.Categories(), id) || (existing[id] && used[id]) {
+			continue
+		}
+		// Kategori yang sudah dihapus manual cukup dilupakan
+		if existing[id] {
+			if _, err := s.ChannelDelete(id); err != nil {
+				log.Printf("Gagal menghapus kategori overflow %s: %v", id, err)
+				continue
+			}
+			log.Printf("Kategori overflow kosong %s dihapus", id)
+		}
+		if err := overflowCategories.Remove(guildID, id); err != nil {
+			log.Printf("Gagal menyimpan kategori overflow: %v", err)
+		}
+	}
+}
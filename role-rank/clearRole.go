@@ -3,17 +3,14 @@ package main
 import (
 	"fmt"
 	"log"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/bwmarrin/discordgo"
 )
 
-var allowedRoles = []string{
-	"1378503364584931328",
-	"1381148056178659399",
-}
-
-func HandleClearCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
+func HandleClearCommand(s Discord, m *discordgo.MessageCreate) {
 	if !strings.HasPrefix(m.Content, "a!clear") {
 		return
 	}
@@ -24,22 +21,18 @@ func HandleClearCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
 		return
 	}
 
+	cfg, ok := GuildConfigFor(m.GuildID)
+	if !ok {
+		return
+	}
+
 	// ✅ Cek apakah pengirim punya role yang diizinkan
-	hasAccess := false
 	member, err := s.GuildMember(m.GuildID, m.Author.ID)
 	if err != nil {
 		log.Printf("Gagal mendapatkan data member: %v", err)
 		return
 	}
-	for _, r := range member.Roles {
-		for _, allowed := range allowedRoles {
-			if r == allowed {
-				hasAccess = true
-				break
-			}
-		}
-	}
-	if !hasAccess {
+	if !cfg.IsModerator(member) {
 		s.ChannelMessageSend(m.ChannelID, "Kamu tidak punya izin untuk menggunakan perintah ini.")
 		return
 	}
@@ -48,29 +41,64 @@ func HandleClearCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
 	targetUserID := args[1]
 	customMessage := strings.Join(args[2:], " ")
 
+	s.ChannelMessageSend(m.ChannelID, clearQuizRoles(s, cfg, m.GuildID, m.Author.ID, targetUserID, customMessage))
+}
+
+// clearQuizRoles mencabut semua role quiz milik user dan mengirim DM berisi
+// pesan moderator. Tindakan ini dicatat di riwayat quiz user. Hasilnya
+// pesan ringkasan untuk moderator.
+func clearQuizRoles(s Discord, cfg *GuildConfig, guildID, moderatorID, targetUserID, customMessage string) string {
 	//Ambil data user target
-	targetMember, err := s.GuildMember(m.GuildID, targetUserID)
+	targetMember, err := s.GuildMember(guildID, targetUserID)
 	if err != nil {
-		s.ChannelMessageSend(m.ChannelID, "Gagal menemukan user.")
 		log.Printf("Gagal menemukan user %s: %v", targetUserID, err)
-		return
+		return "Gagal menemukan user."
 	}
 
 	//Hapus semua role quiz
 	removedRoles := []string{}
-	for _, quiz := range Quizzes {
-		for _, r := range targetMember.Roles {
-			if r == quiz.RoleID {
-				err := s.GuildMemberRoleRemove(m.GuildID, targetUserID, quiz.RoleID)
-				if err != nil {
-					log.Printf("Gagal menghapus role %s: %v", quiz.RoleID, err)
-				} else {
-					removedRoles = append(removedRoles, quiz.Label)
+	var removedIDs []string
+	for _, quiz := range CurrentCatalog().Quizzes {
+		for _, roleID := range []string{cfg.RoleID(quiz), cfg.BadgeRoleID(quiz)} {
+			if roleID == "" || !slices.Contains(targetMember.Roles, roleID) {
+				continue
+			}
+			err := s.GuildMemberRoleRemove(guildID, targetUserID, roleID)
+			if err != nil {
+				log.Printf("Gagal menghapus role %s: %v", roleID, err)
+				logRoleError(s, cfg, targetUserID, nil, "mencabut", roleID, err)
+			} else {
+				label := quiz.Label
+				if roleID != cfg.RoleID(quiz) {
+					label += " (badge)"
 				}
+				removedRoles = append(removedRoles, label)
+				removedIDs = append(removedIDs, roleID)
 			}
 		}
 	}
 
+	now := time.Now()
+	appendHistory(Attempt{
+		GuildID:      guildID,
+		UserID:       targetUserID,
+		Level:        -1,
+		StartedAt:    now,
+		EndedAt:      now,
+		Outcome:      AttemptCleared,
+		Reason:       customMessage,
+		RolesRemoved: removedIDs,
+		ModeratorID:  moderatorID,
+	})
+	postModLog(s, cfg, modLogEvent{
+		Title:       "🧹 Role quiz dicabut moderator",
+		Color:       modLogColorCleared,
+		UserID:      targetUserID,
+		OldRoles:    removedIDs,
+		ModeratorID: moderatorID,
+		Reason:      customMessage,
+	})
+
 	//Kirim DM ke user
 	channel, err := s.UserChannelCreate(targetUserID)
 	if err == nil {
@@ -83,12 +111,11 @@ func HandleClearCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
 		log.Printf("Gagal buka DM ke %s: %v", targetUserID, err)
 	}
 
-	msg := fmt.Sprintf("Role quiz <%s> berhasil dicabut.\n DM terkirim dengan pesan:\n> %s", targetUserID, customMessage)
+	msg := fmt.Sprintf("Role quiz <@%s> berhasil dicabut.\n DM terkirim dengan pesan:\n> %s", targetUserID, customMessage)
 	if len(removedRoles) > 0 {
 		msg += fmt.Sprintf("\nRole yang dihapus: %s", strings.Join(removedRoles, ", "))
 	} else {
 		msg += "\n Tidak ada role quiz yang ditemukan."
 	}
-
-	s.ChannelMessageSend(m.ChannelID, msg)
+	return msg
 }
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Definisi slash command /quiz
+var quizCommand = &discordgo.ApplicationCommand{
+	Name:        "quiz",
+	Description: "Perintah quiz role-rank",
+	Options: []*discordgo.ApplicationCommandOption{
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "clear",
+			Description: "Cabut semua role quiz milik member (moderator)",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionUser,
+					Name:        "user",
+					Description: "Member yang role quiz-nya dicabut",
+					Required:    true,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "reason",
+					Description: "Pesan untuk member, dikirim lewat DM",
+					Required:    true,
+				},
+			},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "close",
+			Description: "Hapus channel quiz ini",
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "history",
+			Description: "Lihat riwayat percobaan quiz member (moderator)",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionUser,
+					Name:        "user",
+					Description: "Member yang riwayatnya dilihat",
+					Required:    true,
+				},
+			},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "reconcile",
+			Description: "Cabut role level lama dari member yang memegang beberapa role level (moderator)",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionUser,
+					Name:        "user",
+					Description: "Member yang dirapikan, kosong = semua member",
+				},
+			},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "reload",
+			Description: "Muat ulang katalog quiz dan konfigurasi guild (moderator)",
+		},
+	},
+}
+
+// RegisterQuizCommands mendaftarkan /quiz di setiap guild yang punya
+// konfigurasi. Command guild langsung aktif, tidak perlu menunggu
+// propagasi command global.
+func RegisterQuizCommands(s Discord) {
+	commands := []*discordgo.ApplicationCommand{quizCommand}
+	for _, guildID := range s.GuildIDs() {
+		if _, ok := GuildConfigFor(guildID); !ok {
+			continue
+		}
+		if _, err := s.ApplicationCommandBulkOverwrite(s.BotUser().ID, guildID, commands); err != nil {
+			log.Printf("Gagal mendaftarkan slash command di guild %s: %v", guildID, err)
+		}
+	}
+}
+
+// HandleQuizCommand menangani /quiz <subcommand>
+func HandleQuizCommand(s Discord, i *discordgo.InteractionCreate) {
+	data := i.ApplicationCommandData()
+	if data.Name != quizCommand.Name || len(data.Options) == 0 || i.Member == nil {
+		return
+	}
+
+	cfg, ok := GuildConfigFor(i.GuildID)
+	if !ok {
+		RespondWithError(s, i, "Server ini belum dikonfigurasi untuk quiz.")
+		return
+	}
+
+	sub := data.Options[0]
+	switch sub.Name {
+	case "clear":
+		if !cfg.IsModerator(i.Member) {
+			RespondWithError(s, i, "Kamu tidak punya izin untuk menggunakan perintah ini.")
+			return
+		}
+
+		var targetUserID, reason string
+		for _, opt := range sub.Options {
+			switch opt.Name {
+			case "user":
+				targetUserID = opt.UserValue(nil).ID
+			case "reason":
+				reason = opt.StringValue()
+			}
+		}
+
+		// Cabut role bisa butuh beberapa request, jadi tunda respon dulu
+		deferEphemeral(s, i)
+		followup(s, i, clearQuizRoles(s, cfg, i.GuildID, i.Member.User.ID, targetUserID, reason))
+
+	case "close":
+		if reason := checkClosableQuizChannel(s, cfg, i.ChannelID); reason != "" {
+			RespondWithError(s, i, reason)
+			return
+		}
+
+		err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Content: "Channel ini akan dihapus..."},
+		})
+		if err != nil {
+			log.Printf("Gagal merespons interaction: %v", err)
+		}
+		deleteQuizChannel(s, i.ChannelID)
+
+	case "history":
+		if !cfg.IsModerator(i.Member) {
+			RespondWithError(s, i, "Kamu tidak punya izin untuk menggunakan perintah ini.")
+			return
+		}
+		if len(sub.Options) == 0 {
+			return
+		}
+		respondHistory(s, i, sub.Options[0].UserValue(nil).ID, 0, discordgo.InteractionResponseChannelMessageWithSource)
+
+	case "reconcile":
+		if !cfg.IsModerator(i.Member) {
+			RespondWithError(s, i, "Kamu tidak punya izin untuk menggunakan perintah ini.")
+			return
+		}
+
+		targetUserID := ""
+		if len(sub.Options) > 0 {
+			targetUserID = sub.Options[0].UserValue(nil).ID
+		}
+
+		deferEphemeral(s, i)
+		followup(s, i, reconcileQuizRoles(s, cfg, i.GuildID, i.Member.User.ID, targetUserID))
+
+	case "reload":
+		if !cfg.IsModerator(i.Member) {
+			RespondWithError(s, i, "Kamu tidak punya izin untuk menggunakan perintah ini.")
+			return
+		}
+
+		deferEphemeral(s, i)
+		followup(s, i, reloadAndRepostSelectors(s, i.Member.User.ID))
+	}
+}
+
+// HandleHistoryPage menangani tombol halaman di embed /quiz history.
+func HandleHistoryPage(s Discord, i *discordgo.InteractionCreate) {
+	cfg, ok := GuildConfigFor(i.GuildID)
+	if !ok || i.Member == nil || !cfg.IsModerator(i.Member) {
+		RespondWithError(s, i, "Kamu tidak punya izin untuk menggunakan perintah ini.")
+		return
+	}
+
+	target, pageStr, _ := strings.Cut(strings.TrimPrefix(i.MessageComponentData().CustomID, historyButtonPrefix), ":")
+	page, err := strconv.Atoi(pageStr)
+	if err != nil || target == "" {
+		return
+	}
+	respondHistory(s, i, target, page, discordgo.InteractionResponseUpdateMessage)
+}
+
+// respondHistory mengirim (atau mengganti) embed riwayat user. Hanya
+// terlihat oleh moderator yang memanggil.
+func respondHistory(s Discord, i *discordgo.InteractionCreate, userID string, page int, respType discordgo.InteractionResponseType) {
+	embed, components, err := historyMessage(i.GuildID, userID, page)
+	if err != nil {
+		log.Printf("Gagal membaca riwayat quiz %s: %v", userID, err)
+		RespondWithError(s, i, "Gagal membaca riwayat quiz.")
+		return
+	}
+
+	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: respType,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     []*discordgo.MessageEmbed{embed},
+			Components: components,
+			Flags:      discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if err != nil {
+		log.Printf("Gagal merespons interaction: %v", err)
+	}
+}
+
+func deferEphemeral(s Discord, i *discordgo.InteractionCreate) {
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Flags: discordgo.MessageFlagsEphemeral},
+	})
+	if err != nil {
+		log.Printf("Gagal merespons interaction: %v", err)
+	}
+}
+
+func followup(s Discord, i *discordgo.InteractionCreate, content string) {
+	_, err := s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+		Content: content,
+		Flags:   discordgo.MessageFlagsEphemeral,
+	})
+	if err != nil {
+		log.Printf("Gagal kirim followup: %v", err)
+	}
+}
@@ -0,0 +1,24 @@
+package main
+
+import "time"
+
+// cooldownUntil mengembalikan waktu user boleh mencoba level ini lagi
+// setelah percobaan yang gagal atau ditinggalkan. Dihitung dari riwayat,
+// jadi tetap berlaku setelah bot restart. Waktu nol berarti tidak ada
+// cooldown.
+func cooldownUntil(guildID, userID, quizID string, quiz QuizInfo) (time.Time, error) {
+	if quiz.Cooldown.Duration <= 0 || history == nil {
+		return time.Time{}, nil
+	}
+
+	// Cukup lihat percobaan terakhir level ini, diambil dari indeks riwayat
+	// di memori
+	last, ok, err := history.LastAttempt(guildID, userID, quizID)
+	if err != nil || !ok || last.Outcome != AttemptAbandoned {
+		return time.Time{}, err
+	}
+	if until := last.EndedAt.Add(quiz.Cooldown.Duration); until.After(time.Now()) {
+		return until, nil
+	}
+	return time.Time{}, nil
+}
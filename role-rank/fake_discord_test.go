@@ -0,0 +1,484 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// fakeGuild adalah implementasi Discord di memori dengan satu guild.
+type fakeGuild struct {
+	mu sync.Mutex
+
+	guildID string
+	bot     *discordgo.User
+	nextID  int
+
+	channels map[string]*discordgo.Channel
+	messages map[string][]*discordgo.Message // channelID -> pesan, terlama duluan
+	members  map[string]*discordgo.Member
+	replies  []string                  // isi respon interaction dan followup, berurutan
+	embeds   []*discordgo.MessageEmbed // embed di respon interaction, berurutan
+	commands []*discordgo.ApplicationCommand
+	threads  map[string][]string          // threadID -> member thread
+	files    map[string][]*discordgo.File // channelID -> file yang di-upload
+	lookups  map[string]int               // channelID -> jumlah panggilan Channel
+
+	roleErrors map[string]error // roleID -> error dari GuildMemberRoleAdd/Remove
+}
+
+var _ Discord = (*fakeGuild)(nil)
+
+func newFakeGuild(guildID string) *fakeGuild {
+	return &fakeGuild{
+		guildID:  guildID,
+		bot:      &discordgo.User{ID: "bot", Username: "role-rank", Bot: true},
+		channels: make(map[string]*discordgo.Channel),
+		messages: make(map[string][]*discordgo.Message),
+		members:  make(map[string]*discordgo.Member),
+		threads:  make(map[string][]string),
+		files:    make(map[string][]*discordgo.File),
+		lookups:  make(map[string]int),
+
+		roleErrors: make(map[string]error),
+	}
+}
+
+func (f *fakeGuild) id(prefix string) string {
+	f.nextID++
+	return fmt.Sprintf("%s-%d", prefix, f.nextID)
+}
+
+// Kode error Discord untuk objek yang tidak ditemukan
+var unknownCodes = map[string]int{
+	"channel": discordgo.ErrCodeUnknownChannel,
+	"thread":  discordgo.ErrCodeUnknownChannel,
+	"guild":   discordgo.ErrCodeUnknownGuild,
+	"member":  discordgo.ErrCodeUnknownMember,
+	"message": discordgo.ErrCodeUnknownMessage,
+}
+
+// unknown meniru respon 404 dari REST API Discord.
+func (f *fakeGuild) unknown(kind, id string) error {
+	msg := &discordgo.APIErrorMessage{Code: unknownCodes[kind], Message: fmt.Sprintf("Unknown %s %s", kind, id)}
+	body, _ := json.Marshal(msg)
+	return &discordgo.RESTError{
+		Response:     &http.Response{StatusCode: http.StatusNotFound, Status: "404 Not Found"},
+		ResponseBody: body,
+		Message:      msg,
+	}
+}
+
+// === Helper untuk test ===
+
+func (f *fakeGuild) addMember(userID string, roles ...string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.members[userID] = &discordgo.Member{
+		GuildID: f.guildID,
+		User:    &discordgo.User{ID: userID, Username: userID},
+		Roles:   append([]string(nil), roles...),
+	}
+}
+
+func (f *fakeGuild) setNick(userID, nick string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.members[userID].Nick = nick
+}
+
+func (f *fakeGuild) addChannel(ch *discordgo.Channel) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	ch.GuildID = f.guildID
+	f.channels[ch.ID] = ch
+}
+
+func (f *fakeGuild) memberRoles(userID string) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	member, ok := f.members[userID]
+	if !ok {
+		return nil
+	}
+	roles := append([]string(nil), member.Roles...)
+	sort.Strings(roles)
+	return roles
+}
+
+func (f *fakeGuild) channelsUnder(parentID string) []*discordgo.Channel {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var list []*discordgo.Channel
+	for _, ch := range f.channels {
+		if ch.ParentID == parentID {
+			list = append(list, ch)
+		}
+	}
+	return list
+}
+
+func (f *fakeGuild) hasChannel(channelID string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	_, ok := f.channels[channelID]
+	return ok
+}
+
+func (f *fakeGuild) channel(channelID string) *discordgo.Channel {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	ch, ok := f.channels[channelID]
+	if !ok {
+		return nil
+	}
+	return copyChannel(ch)
+}
+
+// copyChannel menyalin channel supaya pemanggil tidak berbagi data dengan
+// fake, sama seperti respon REST
+func copyChannel(ch *discordgo.Channel) *discordgo.Channel {
+	copied := *ch
+	if ch.ThreadMetadata != nil {
+		meta := *ch.ThreadMetadata
+		copied.ThreadMetadata = &meta
+	}
+	return &copied
+}
+
+func (f *fakeGuild) threadMembers(threadID string) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.threads[threadID]...)
+}
+
+func (f *fakeGuild) lastMessage(channelID string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	msgs := f.messages[channelID]
+	if len(msgs) == 0 {
+		return ""
+	}
+	return msgs[len(msgs)-1].Content
+}
+
+func (f *fakeGuild) lastResponse() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.replies) == 0 {
+		return ""
+	}
+	return f.replies[len(f.replies)-1]
+}
+
+func (f *fakeGuild) lastEmbed() *discordgo.MessageEmbed {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.embeds) == 0 {
+		return nil
+	}
+	return f.embeds[len(f.embeds)-1]
+}
+
+func (f *fakeGuild) post(channelID string, msg *discordgo.Message) (*discordgo.Message, error) {
+	if _, ok := f.channels[channelID]; !ok {
+		return nil, f.unknown("channel", channelID)
+	}
+	msg.ID = f.id("msg")
+	msg.ChannelID = channelID
+	msg.GuildID = f.guildID
+	msg.Timestamp = time.Now()
+	if msg.Author == nil {
+		msg.Author = f.bot
+	}
+	f.messages[channelID] = append(f.messages[channelID], msg)
+	return msg, nil
+}
+
+// === Discord ===
+
+func (f *fakeGuild) Channel(channelID string, _ ...discordgo.RequestOption) (*discordgo.Channel, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.lookups[channelID]++
+	ch, ok := f.channels[channelID]
+	if !ok {
+		return nil, f.unknown("channel", channelID)
+	}
+	return copyChannel(ch), nil
+}
+
+func (f *fakeGuild) ChannelDelete(channelID string, _ ...discordgo.RequestOption) (*discordgo.Channel, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	ch, ok := f.channels[channelID]
+	if !ok {
+		return nil, f.unknown("channel", channelID)
+	}
+	delete(f.channels, channelID)
+	delete(f.messages, channelID)
+	return ch, nil
+}
+
+func (f *fakeGuild) ChannelMessages(channelID string, limit int, beforeID, _, _ string, _ ...discordgo.RequestOption) ([]*discordgo.Message, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.channels[channelID]; !ok {
+		return nil, f.unknown("channel", channelID)
+	}
+	msgs := f.messages[channelID]
+	if beforeID != "" {
+		for n, msg := range msgs {
+			if msg.ID == beforeID {
+				msgs = msgs[:n]
+				break
+			}
+		}
+	}
+	// Discord mengembalikan pesan terbaru lebih dulu
+	var list []*discordgo.Message
+	for n := len(msgs) - 1; n >= 0 && len(list) < limit; n-- {
+		list = append(list, msgs[n])
+	}
+	return list, nil
+}
+
+func (f *fakeGuild) ChannelMessageSend(channelID string, content string, _ ...discordgo.RequestOption) (*discordgo.Message, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.post(channelID, &discordgo.Message{Content: content})
+}
+
+func (f *fakeGuild) ChannelMessageSendComplex(channelID string, data *discordgo.MessageSend, _ ...discordgo.RequestOption) (*discordgo.Message, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.channels[channelID]; ok {
+		f.files[channelID] = append(f.files[channelID], data.Files...)
+	}
+	return f.post(channelID, &discordgo.Message{
+		Content:    data.Content,
+		Embeds:     data.Embeds,
+		Components: data.Components,
+	})
+}
+
+func (f *fakeGuild) ChannelMessageDelete(channelID, messageID string, _ ...discordgo.RequestOption) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	msgs := f.messages[channelID]
+	for n, msg := range msgs {
+		if msg.ID == messageID {
+			f.messages[channelID] = append(msgs[:n], msgs[n+1:]...)
+			return nil
+		}
+	}
+	return f.unknown("message", messageID)
+}
+
+func (f *fakeGuild) ChannelEditComplex(channelID string, data *discordgo.ChannelEdit, _ ...discordgo.RequestOption) (*discordgo.Channel, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	ch, ok := f.channels[channelID]
+	if !ok {
+		return nil, f.unknown("channel", channelID)
+	}
+	if data.Archived != nil || data.Locked != nil {
+		if ch.ThreadMetadata == nil {
+			return nil, fmt.Errorf("channel %s is not a thread", channelID)
+		}
+		if data.Archived != nil {
+			ch.ThreadMetadata.Archived = *data.Archived
+		}
+		if data.Locked != nil {
+			ch.ThreadMetadata.Locked = *data.Locked
+		}
+	}
+	return copyChannel(ch), nil
+}
+
+func (f *fakeGuild) ThreadStartComplex(channelID string, data *discordgo.ThreadStart, _ ...discordgo.RequestOption) (*discordgo.Channel, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.channels[channelID]; !ok {
+		return nil, f.unknown("channel", channelID)
+	}
+	ch := &discordgo.Channel{
+		ID:             f.id("thread"),
+		GuildID:        f.guildID,
+		Name:           data.Name,
+		Type:           data.Type,
+		ParentID:       channelID,
+		ThreadMetadata: &discordgo.ThreadMetadata{AutoArchiveDuration: data.AutoArchiveDuration},
+	}
+	f.channels[ch.ID] = ch
+	f.threads[ch.ID] = []string{f.bot.ID}
+	return ch, nil
+}
+
+func (f *fakeGuild) ThreadMemberAdd(threadID, memberID string, _ ...discordgo.RequestOption) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.threads[threadID]; !ok {
+		return f.unknown("thread", threadID)
+	}
+	f.threads[threadID] = append(f.threads[threadID], memberID)
+	return nil
+}
+
+func (f *fakeGuild) GuildChannels(guildID string, _ ...discordgo.RequestOption) ([]*discordgo.Channel, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if guildID != f.guildID {
+		return nil, f.unknown("guild", guildID)
+	}
+	list := make([]*discordgo.Channel, 0, len(f.channels))
+	for _, ch := range f.channels {
+		list = append(list, ch)
+	}
+	return list, nil
+}
+
+func (f *fakeGuild) GuildChannelCreateComplex(guildID string, data discordgo.GuildChannelCreateData, _ ...discordgo.RequestOption) (*discordgo.Channel, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if guildID != f.guildID {
+		return nil, f.unknown("guild", guildID)
+	}
+	ch := &discordgo.Channel{
+		ID:                   f.id("channel"),
+		GuildID:              guildID,
+		Name:                 data.Name,
+		Type:                 data.Type,
+		ParentID:             data.ParentID,
+		PermissionOverwrites: data.PermissionOverwrites,
+	}
+	f.channels[ch.ID] = ch
+	return ch, nil
+}
+
+func (f *fakeGuild) GuildMember(guildID, userID string, _ ...discordgo.RequestOption) (*discordgo.Member, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	member, ok := f.members[userID]
+	if guildID != f.guildID || !ok {
+		return nil, f.unknown("member", userID)
+	}
+	copied := *member
+	copied.Roles = append([]string(nil), member.Roles...)
+	return &copied, nil
+}
+
+func (f *fakeGuild) GuildMembers(guildID string, after string, limit int, _ ...discordgo.RequestOption) ([]*discordgo.Member, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if guildID != f.guildID {
+		return nil, f.unknown("guild", guildID)
+	}
+	// Discord mengurutkan member berdasarkan user ID
+	ids := make([]string, 0, len(f.members))
+	for id := range f.members {
+		if id > after {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	var list []*discordgo.Member
+	for _, id := range ids[:min(limit, len(ids))] {
+		copied := *f.members[id]
+		copied.Roles = append([]string(nil), f.members[id].Roles...)
+		list = append(list, &copied)
+	}
+	return list, nil
+}
+
+func (f *fakeGuild) GuildMemberRoleAdd(guildID, userID, roleID string, _ ...discordgo.RequestOption) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.roleErrors[roleID]; err != nil {
+		return err
+	}
+	member, ok := f.members[userID]
+	if guildID != f.guildID || !ok {
+		return f.unknown("member", userID)
+	}
+	for _, r := range member.Roles {
+		if r == roleID {
+			return nil
+		}
+	}
+	member.Roles = append(member.Roles, roleID)
+	return nil
+}
+
+func (f *fakeGuild) GuildMemberRoleRemove(guildID, userID, roleID string, _ ...discordgo.RequestOption) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.roleErrors[roleID]; err != nil {
+		return err
+	}
+	member, ok := f.members[userID]
+	if guildID != f.guildID || !ok {
+		return f.unknown("member", userID)
+	}
+	for n, r := range member.Roles {
+		if r == roleID {
+			member.Roles = append(member.Roles[:n], member.Roles[n+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+func (f *fakeGuild) UserChannelCreate(recipientID string, _ ...discordgo.RequestOption) (*discordgo.Channel, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	id := "dm-" + recipientID
+	if _, ok := f.channels[id]; !ok {
+		f.channels[id] = &discordgo.Channel{ID: id, Type: discordgo.ChannelTypeDM}
+	}
+	return f.channels[id], nil
+}
+
+func (f *fakeGuild) InteractionRespond(_ *discordgo.Interaction, resp *discordgo.InteractionResponse, _ ...discordgo.RequestOption) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if resp.Data != nil && resp.Data.Content != "" {
+		f.replies = append(f.replies, resp.Data.Content)
+	}
+	if resp.Data != nil {
+		f.embeds = append(f.embeds, resp.Data.Embeds...)
+	}
+	return nil
+}
+
+func (f *fakeGuild) FollowupMessageCreate(_ *discordgo.Interaction, _ bool, data *discordgo.WebhookParams, _ ...discordgo.RequestOption) (*discordgo.Message, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.replies = append(f.replies, data.Content)
+	return &discordgo.Message{ID: f.id("followup"), Content: data.Content}, nil
+}
+
+func (f *fakeGuild) FollowupMessageDelete(_ *discordgo.Interaction, _ string, _ ...discordgo.RequestOption) error {
+	return nil
+}
+
+func (f *fakeGuild) ApplicationCommandBulkOverwrite(_ string, guildID string, commands []*discordgo.ApplicationCommand, _ ...discordgo.RequestOption) ([]*discordgo.ApplicationCommand, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if guildID != f.guildID {
+		return nil, f.unknown("guild", guildID)
+	}
+	f.commands = commands
+	return commands, nil
+}
+
+func (f *fakeGuild) UpdateGameStatus(int, string) error { return nil }
+
+func (f *fakeGuild) BotUser() *discordgo.User { return f.bot }
+
+func (f *fakeGuild) GuildIDs() []string { return []string{f.guildID} }
@@ -0,0 +1,322 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"sync/atomic"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Key guilds.json harus ID server Discord (snowflake)
+var guildIDPattern = regexp.MustCompile(`^\d{17,20}$`)
+
+const (
+	// Contoh konfigurasi yang ikut di repo; disalin ke guilds.json
+	guildConfigExamplePath = "guilds.example.json"
+	// Key di contoh konfigurasi yang harus diganti ID server
+	guildIDPlaceholder = "GANTI_DENGAN_ID_SERVER"
+)
+
+// RoleStrategy menentukan role apa saja yang dipegang member setelah lulus.
+type RoleStrategy string
+
+const (
+	RoleReplace    RoleStrategy = "replace"    // hanya role level tertinggi (default)
+	RoleCumulative RoleStrategy = "cumulative" // semua role level yang pernah lulus
+	RoleBadges     RoleStrategy = "badges"     // role level tertinggi + badge per level yang lulus
+)
+
+// QuizMode menentukan tempat quiz dikerjakan.
+type QuizMode string
+
+const (
+	QuizModeChannel QuizMode = "channel" // channel teks private di kategori quiz (default)
+	QuizModeThread  QuizMode = "thread"  // thread private di channel selector
+)
+
+// Durasi auto-archive thread yang diterima Discord, dalam menit
+var threadArchiveDurations = []int{60, 1440, 4320, 10080}
+
+// GuildConfig adalah pengaturan role-rank untuk satu server.
+type GuildConfig struct {
+	SelectorChannelID string            `json:"selectorChannelId"`
+	QuizCategoryID    string            `json:"quizCategoryId,omitempty"`
+	QuizCategoryIDs   []string          `json:"quizCategoryIds,omitempty"` // kategori tambahan, dipakai berurutan saat yang lain penuh
+	ModeratorRoles    []string          `json:"moderatorRoles"`
+	Roles             map[string]string `json:"roles,omitempty"` // quiz value -> role ID, kosong = roleId dari katalog
+
+	// Aktifkan a!clear, a!del dan a!reload di samping slash command
+	LegacyPrefixCommands bool `json:"legacyPrefixCommands,omitempty"`
+
+	// Setiap level hanya bisa dipilih oleh pemegang role level tepat di
+	// bawahnya, selain prasyarat di katalog
+	StrictLadder bool `json:"strictLadder,omitempty"`
+
+	// Buat kategori baru otomatis kalau semua kategori quiz penuh. Nama
+	// kategori baru diawali OverflowCategoryName; ID-nya disimpan dan hanya
+	// kategori itu yang dianggap overflow.
+	AutoOverflowCategories bool   `json:"autoOverflowCategories,omitempty"`
+	OverflowCategoryName   string `json:"overflowCategoryName,omitempty"` // kosong = "Quiz"
+
+	// Tempat quiz dikerjakan, kosong = channel
+	QuizMode QuizMode `json:"quizMode,omitempty"`
+	// Menit tanpa aktivitas sebelum thread quiz diarsipkan Discord, 0 = 1440
+	ThreadAutoArchive int `json:"threadAutoArchive,omitempty"`
+
+	// Cara role diberikan saat upgrade, kosong = replace
+	RoleStrategy RoleStrategy `json:"roleStrategy,omitempty"`
+	// quiz value -> role badge "lulus", hanya untuk strategi badges
+	BadgeRoles map[string]string `json:"badgeRoles,omitempty"`
+
+	// Batas quiz yang terbuka bersamaan, 0 = tanpa batas. User yang datang
+	// saat penuh masuk antrian sampai ada quiz yang selesai.
+	MaxOpenQuizzes int `json:"maxOpenQuizzes,omitempty"`
+	// Panjang antrian saat MaxOpenQuizzes tercapai, 0 = 50
+	MaxQueueLength int `json:"maxQueueLength,omitempty"`
+
+	// Channel moderator untuk transkrip quiz dan log role
+	ModLogChannelID string `json:"modLogChannelId,omitempty"`
+	// Folder lokal untuk transkrip channel quiz yang dihapus, kosong = tidak
+	// disimpan ke disk. File yang lebih tua dari TranscriptRetention dihapus
+	// sweeper, 0 = disimpan selamanya.
+	TranscriptDir       string   `json:"transcriptDir,omitempty"`
+	TranscriptRetention Duration `json:"transcriptRetention,omitzero"`
+}
+
+// GuildConfigs memetakan guild ID ke konfigurasinya.
+type GuildConfigs map[string]*GuildConfig
+
+var (
+	activeGuilds    atomic.Pointer[GuildConfigs]
+	guildConfigPath = "guilds.json"
+)
+
+// GuildConfigFor mengembalikan konfigurasi guild. Guild yang tidak ada di
+// guilds.json dianggap belum dikonfigurasi, bot tidak melakukan apa-apa di
+// sana.
+func GuildConfigFor(guildID string) (*GuildConfig, bool) {
+	guilds := activeGuilds.Load()
+	if guilds == nil {
+		return nil, false
+	}
+	cfg, ok := (*guilds)[guildID]
+	return cfg, ok
+}
+
+// RoleID mengembalikan role untuk quiz di guild ini.
+func (c *GuildConfig) RoleID(quiz QuizInfo) string {
+	if roleID, ok := c.Roles[quiz.Value]; ok {
+		return roleID
+	}
+	return quiz.RoleID
+}
+
+// Categories mengembalikan kategori quiz yang dikonfigurasi, urut sesuai
+// prioritas.
+func (c *GuildConfig) Categories() []string {
+	var list []string
+	for _, id := range append([]string{c.QuizCategoryID}, c.QuizCategoryIDs...) {
+		if id != "" && !slices.Contains(list, id) {
+			list = append(list, id)
+		}
+	}
+	return list
+}
+
+// OverflowName mengembalikan awalan nama kategori overflow.
+func (c *GuildConfig) OverflowName() string {
+	if c.OverflowCategoryName == "" {
+		return "Quiz"
+	}
+	return c.OverflowCategoryName
+}
+
+// QueueLimit mengembalikan panjang maksimal antrian quiz.
+func (c *GuildConfig) QueueLimit() int {
+	if c.MaxQueueLength == 0 {
+		return defaultMaxQueueLength
+	}
+	return c.MaxQueueLength
+}
+
+// Mode mengembalikan tempat quiz dikerjakan di guild ini.
+func (c *GuildConfig) Mode() QuizMode {
+	if c.QuizMode == "" {
+		return QuizModeChannel
+	}
+	return c.QuizMode
+}
+
+// ThreadArchiveMinutes mengembalikan durasi auto-archive thread quiz.
+func (c *GuildConfig) ThreadArchiveMinutes() int {
+	if c.ThreadAutoArchive == 0 {
+		return 1440
+	}
+	return c.ThreadAutoArchive
+}
+
+// Strategy mengembalikan strategi role guild ini.
+func (c *GuildConfig) Strategy() RoleStrategy {
+	if c.RoleStrategy == "" {
+		return RoleReplace
+	}
+	return c.RoleStrategy
+}
+
+// BadgeRoleID mengembalikan role badge untuk quiz, kosong kalau guild
+// tidak memakai badge atau level ini tidak punya badge.
+func (c *GuildConfig) BadgeRoleID(quiz QuizInfo) string {
+	if c.Strategy() != RoleBadges {
+		return ""
+	}
+	return c.BadgeRoles[quiz.Value]
+}
+
+// HasPassed cek apakah member memegang role level atau badge quiz ini.
+func (c *GuildConfig) HasPassed(member *discordgo.Member, quiz QuizInfo) bool {
+	badge := c.BadgeRoleID(quiz)
+	return slices.Contains(member.Roles, c.RoleID(quiz)) || (badge != "" && slices.Contains(member.Roles, badge))
+}
+
+// IsModerator cek apakah member punya salah satu role moderator guild ini.
+func (c *GuildConfig) IsModerator(member *discordgo.Member) bool {
+	for _, r := range member.Roles {
+		for _, allowed := range c.ModeratorRoles {
+			if r == allowed {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// LoadGuildConfigs membaca konfigurasi guild dan memvalidasinya terhadap
+// katalog quiz yang akan dipakai.
+func LoadGuildConfigs(path string, catalog *QuizCatalog) (GuildConfigs, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("konfigurasi guild %s belum ada: salin %s ke %s lalu isi ID server Discord", path, guildConfigExamplePath, path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("gagal membaca konfigurasi guild %s: %w", path, err)
+	}
+	defer f.Close()
+
+	dec := json.NewDecoder(f)
+	dec.DisallowUnknownFields()
+
+	var file struct {
+		Guilds GuildConfigs `json:"guilds"`
+	}
+	if err := dec.Decode(&file); err != nil {
+		return nil, fmt.Errorf("konfigurasi guild %s tidak valid: %w", path, err)
+	}
+
+	if err := validateGuildConfigs(file.Guilds, catalog); err != nil {
+		return nil, fmt.Errorf("konfigurasi guild %s tidak valid:\n%w", path, err)
+	}
+	return file.Guilds, nil
+}
+
+func validateGuildConfigs(guilds GuildConfigs, catalog *QuizCatalog) error {
+	if len(guilds) == 0 {
+		return errors.New("tidak ada guild yang dikonfigurasi")
+	}
+
+	var errs []error
+	for guildID, cfg := range guilds {
+		fail := func(format string, args ...any) {
+			errs = append(errs, fmt.Errorf("guild %s: "+format, append([]any{guildID}, args...)...))
+		}
+		if guildID == guildIDPlaceholder {
+			fail("ganti key %s dengan ID server Discord (klik kanan server → Copy Server ID)", guildIDPlaceholder)
+		} else if !guildIDPattern.MatchString(guildID) {
+			fail("key harus ID server Discord")
+		}
+		if cfg == nil {
+			fail("konfigurasi kosong")
+			continue
+		}
+
+		if cfg.SelectorChannelID == "" {
+			fail("selectorChannelId wajib diisi")
+		}
+		if len(cfg.Categories()) == 0 && !cfg.AutoOverflowCategories {
+			fail("quizCategoryId atau quizCategoryIds wajib diisi")
+		}
+		if len(cfg.ModeratorRoles) == 0 {
+			fail("minimal satu moderatorRoles")
+		}
+
+		for quizID := range cfg.Roles {
+			if _, ok := catalog.Quizzes[quizID]; !ok {
+				fail("roles berisi quiz %s yang tidak ada di katalog", quizID)
+			}
+		}
+
+		if cfg.MaxOpenQuizzes < 0 {
+			fail("maxOpenQuizzes tidak boleh negatif")
+		}
+		if cfg.MaxQueueLength < 0 {
+			fail("maxQueueLength tidak boleh negatif")
+		}
+
+		if cfg.TranscriptRetention.Duration < 0 {
+			fail("transcriptRetention tidak boleh negatif")
+		}
+		if cfg.TranscriptRetention.Duration > 0 && cfg.TranscriptDir == "" {
+			fail("transcriptRetention butuh transcriptDir")
+		}
+
+		switch cfg.Mode() {
+		case QuizModeChannel, QuizModeThread:
+		default:
+			fail("quizMode %q tidak dikenal (channel atau thread)", cfg.QuizMode)
+		}
+		if cfg.ThreadAutoArchive != 0 && !slices.Contains(threadArchiveDurations, cfg.ThreadAutoArchive) {
+			fail("threadAutoArchive %d tidak valid, pilih salah satu dari %v", cfg.ThreadAutoArchive, threadArchiveDurations)
+		}
+
+		switch cfg.Strategy() {
+		case RoleReplace, RoleCumulative:
+			if len(cfg.BadgeRoles) > 0 {
+				fail("badgeRoles hanya dipakai dengan roleStrategy %q", RoleBadges)
+			}
+		case RoleBadges:
+			if len(cfg.BadgeRoles) == 0 {
+				fail("roleStrategy %q butuh badgeRoles", RoleBadges)
+			}
+		default:
+			fail("roleStrategy %q tidak dikenal (replace, cumulative atau badges)", cfg.RoleStrategy)
+		}
+		for quizID := range cfg.BadgeRoles {
+			if _, ok := catalog.Quizzes[quizID]; !ok {
+				fail("badgeRoles berisi quiz %s yang tidak ada di katalog", quizID)
+			}
+		}
+
+		// Role ladder harus unik per guild setelah override diterapkan
+		seen := make(map[string]string)
+		for _, key := range catalog.Order {
+			roleID := cfg.RoleID(catalog.Quizzes[key])
+			if prev, dup := seen[roleID]; dup {
+				fail("role %s dipakai oleh %s dan %s", roleID, prev, key)
+				continue
+			}
+			seen[roleID] = key
+		}
+		for key, roleID := range cfg.BadgeRoles {
+			if prev, dup := seen[roleID]; dup {
+				fail("role badge %s untuk %s sudah dipakai oleh %s", roleID, key, prev)
+				continue
+			}
+			seen[roleID] = key
+		}
+	}
+	return errors.Join(errs...)
+}
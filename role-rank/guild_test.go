@@ -0,0 +1,178 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestValidateGuildConfigs(t *testing.T) {
+	catalog, err := LoadQuizCatalog("quizzes.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		mutate  func(guilds GuildConfigs)
+		wantErr string // kosong = valid
+	}{
+		{
+			name:   "test config",
+			mutate: func(GuildConfigs) {},
+		},
+		{
+			name: "default key",
+			mutate: func(g GuildConfigs) {
+				g["default"] = g[testGuildID]
+				delete(g, testGuildID)
+			},
+			wantErr: "guild default: key harus ID server Discord",
+		},
+		{
+			name:    "missing selector",
+			mutate:  func(g GuildConfigs) { g[testGuildID].SelectorChannelID = "" },
+			wantErr: "selectorChannelId wajib diisi",
+		},
+		{
+			name: "no category",
+			mutate: func(g GuildConfigs) {
+				g[testGuildID].QuizCategoryID = ""
+			},
+			wantErr: "quizCategoryId atau quizCategoryIds wajib diisi",
+		},
+		{
+			name:    "unknown strategy",
+			mutate:  func(g GuildConfigs) { g[testGuildID].RoleStrategy = "random" },
+			wantErr: `roleStrategy "random" tidak dikenal`,
+		},
+		{
+			name:    "badges without badge roles",
+			mutate:  func(g GuildConfigs) { g[testGuildID].RoleStrategy = RoleBadges },
+			wantErr: "butuh badgeRoles",
+		},
+		{
+			name:    "unknown mode",
+			mutate:  func(g GuildConfigs) { g[testGuildID].QuizMode = "forum" },
+			wantErr: `quizMode "forum" tidak dikenal`,
+		},
+		{
+			name:    "bad thread archive duration",
+			mutate:  func(g GuildConfigs) { g[testGuildID].ThreadAutoArchive = 30 },
+			wantErr: "threadAutoArchive 30 tidak valid",
+		},
+		{
+			name:    "role override for unknown quiz",
+			mutate:  func(g GuildConfigs) { g[testGuildID].Roles = map[string]string{"Level_99": "1"} },
+			wantErr: "roles berisi quiz Level_99",
+		},
+		{
+			name: "duplicate role",
+			mutate: func(g GuildConfigs) {
+				g[testGuildID].Roles = map[string]string{"Level_2": catalog.Quizzes["Level_1"].RoleID}
+			},
+			wantErr: "dipakai oleh Level_1 dan Level_2",
+		},
+		{
+			name:    "negative open quiz limit",
+			mutate:  func(g GuildConfigs) { g[testGuildID].MaxOpenQuizzes = -1 },
+			wantErr: "maxOpenQuizzes tidak boleh negatif",
+		},
+		{
+			name:    "retention without directory",
+			mutate:  func(g GuildConfigs) { g[testGuildID].TranscriptRetention = Duration{time.Hour} },
+			wantErr: "transcriptRetention butuh transcriptDir",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			guilds, err := LoadGuildConfigs("testdata/guilds.json", catalog)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.mutate(guilds)
+			err = validateGuildConfigs(guilds, catalog)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("validateGuildConfigs: %v", err)
+			case tt.wantErr != "" && err == nil:
+				t.Fatalf("validateGuildConfigs accepted the config, want error containing %q", tt.wantErr)
+			case tt.wantErr != "" && !strings.Contains(err.Error(), tt.wantErr):
+				t.Errorf("error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestShippedGuildConfig memastikan contoh konfigurasi di repo hanya
+// ditolak karena ID server belum diisi, dan guilds.json yang belum dibuat
+// menjelaskan cara membuatnya.
+func TestShippedGuildConfig(t *testing.T) {
+	catalog, err := LoadQuizCatalog("quizzes.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = LoadGuildConfigs(guildConfigExamplePath, catalog)
+	if err == nil || !strings.Contains(err.Error(), "ganti key "+guildIDPlaceholder) {
+		t.Fatalf("example config error = %v, want the placeholder message", err)
+	}
+	if got := strings.Count(err.Error(), "\n"); got != 1 {
+		t.Errorf("example config has other problems besides the placeholder:\n%v", err)
+	}
+
+	data, err := os.ReadFile(guildConfigExamplePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "guilds.json")
+	filled := strings.Replace(string(data), guildIDPlaceholder, testGuildID, 1)
+	if err := os.WriteFile(path, []byte(filled), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	guilds, err := LoadGuildConfigs(path, catalog)
+	if err != nil {
+		t.Fatalf("example config with a server ID: %v", err)
+	}
+	if _, ok := guilds[testGuildID]; !ok {
+		t.Errorf("guilds = %v", guilds)
+	}
+
+	_, err = LoadGuildConfigs(filepath.Join(t.TempDir(), "guilds.json"), catalog)
+	if err == nil || !strings.Contains(err.Error(), "salin "+guildConfigExamplePath) {
+		t.Errorf("missing config error = %v", err)
+	}
+}
+
+func TestUnknownGuildIsUnconfigured(t *testing.T) {
+	const otherGuildID, userID = "300000000000000000", "100000000000000001"
+	f, cfg := setupFlowTest(t)
+	if _, ok := GuildConfigFor(otherGuildID); ok {
+		t.Fatal("unknown guild got a configuration")
+	}
+
+	// Selector di guild lain tidak boleh membuat channel memakai
+	// kategori guild yang terdaftar
+	OnInteraction(f, &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+		ID:        "interaction-other",
+		Type:      discordgo.InteractionMessageComponent,
+		GuildID:   otherGuildID,
+		ChannelID: "selector-other",
+		Member:    &discordgo.Member{User: &discordgo.User{ID: userID, Username: userID}},
+		Data:      discordgo.MessageComponentInteractionData{CustomID: "quiz_select", Values: []string{"Level_1"}},
+	}})
+	provisioner.Wait()
+
+	if got := f.lastResponse(); !strings.Contains(got, "belum dikonfigurasi") {
+		t.Errorf("response = %q", got)
+	}
+	if _, ok := sessions.Get(userID); ok {
+		t.Error("session created in an unconfigured guild")
+	}
+	if got := len(f.channelsUnder(cfg.QuizCategoryID)); got != 1 {
+		t.Errorf("quiz category has %d channels, want only the selector", got)
+	}
+}
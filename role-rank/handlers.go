@@ -1,16 +1,22 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"log"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/bwmarrin/discordgo"
 )
 
-func OnReady(s *discordgo.Session, r *discordgo.Ready) {
-	fmt.Printf("Bot logged in as %s\n", s.State.User.Username)
+func OnReady(s Discord, r *discordgo.Ready) {
+	fmt.Printf("Bot logged in as %s\n", s.BotUser().Username)
+
+	// Daftarkan slash command /quiz di setiap guild
+	RegisterQuizCommands(s)
 
 	// Set bot status
 	err := s.UpdateGameStatus(0, "Japanese Quiz Master 🎌")
@@ -18,130 +24,203 @@ func OnReady(s *discordgo.Session, r *discordgo.Ready) {
 		log.Printf("Failed to set status: %v", err)
 	}
 
-	// Send initial quiz selector to designated channel
-	channelID := "1392463011301691442" // Replace with your channel ID
-	SendQuizSelector(s, channelID)
+	// Pulihkan sesi quiz dari sebelum restart
+	RestoreSessions(s, sessionStore)
+
+	// Send initial quiz selector to every configured guild
+	SendAllQuizSelectors(s)
 
 	// Start background sweeper to remove inactive quiz channels (1 day)
 	StartInactiveChannelSweeper(s)
 }
 
-var quizCategoryID = "1392514838118531132" // ganti dengan ID kategori quiz kamu
-var quizChannelTTL = 24 * time.Hour        // durasi tidak aktif sebelum channel quiz dihapus
-func OnInteraction(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	if i.Type != discordgo.InteractionMessageComponent {
-		return
-	}
+var (
+	quizChannelTTL      = 24 * time.Hour   // durasi tidak aktif sebelum channel quiz dihapus
+	quizCleanupDelay    = 30 * time.Second // jeda sebelum channel quiz yang selesai dihapus
+	quizCloseDelay      = 1 * time.Second  // jeda setelah konfirmasi a!del / /quiz close
+	followupDeleteDelay = 10 * time.Second // umur pesan ephemeral setelah channel dibuat
+)
 
-	if i.MessageComponentData().CustomID != "quiz_select" {
-		return
+func OnInteraction(s Discord, i *discordgo.InteractionCreate) {
+	switch i.Type {
+	case discordgo.InteractionApplicationCommand:
+		HandleQuizCommand(s, i)
+	case discordgo.InteractionMessageComponent:
+		switch customID := i.MessageComponentData().CustomID; {
+		case customID == "quiz_select":
+			HandleQuizSelect(s, i)
+		case strings.HasPrefix(customID, historyButtonPrefix):
+			HandleHistoryPage(s, i)
+		}
 	}
+}
 
+func HandleQuizSelect(s Discord, i *discordgo.InteractionCreate) {
 	user := i.Member.User
 	guildID := i.GuildID
+	cfg, ok := GuildConfigFor(guildID)
+	if !ok {
+		RespondWithError(s, i, "Server ini belum dikonfigurasi untuk quiz.")
+		return
+	}
 	quizID := i.MessageComponentData().Values[0]
-	quiz, ok := Quizzes[quizID]
+	catalog := CurrentCatalog()
+	quiz, ok := catalog.Quizzes[quizID]
 	if !ok {
 		RespondWithError(s, i, "Quiz tidak ditemukan!")
 		return
 	}
 
 	// 💥 CEK apakah session nyangkut tapi channel-nya sudah tidak ada
-	if session, exists := activeQuizzes[user.ID]; exists {
-		_, err := s.Channel(session.ThreadID)
+	if session, exists := sessions.Get(user.ID); exists {
+		channel, err := s.Channel(session.ThreadID)
 		if err != nil {
 			// channel sudah dihapus → bersihkan sesi
 			log.Printf("Channel quiz milik user %s sudah tidak ada. Membersihkan sesi.", user.ID)
-			delete(activeQuizzes, user.ID)
+			if session, ok := sessions.Delete(user.ID); ok {
+				recordAttempt(session, AttemptAbandoned, "channel quiz sudah tidak ada", nil, nil)
+			}
+		} else if isArchivedThread(channel) {
+			// thread sudah diarsipkan Discord → lepas sesi tanpa menunggu sweeper
+			releaseArchivedThread(s, channel)
 		} else {
 			RespondWithError(s, i, "Kamu sudah memiliki quiz aktif. Selesaikan dulu yang sebelumnya ya!")
 			return
 		}
 	}
 
-	channelName := fmt.Sprintf("quiz-%s-%s", strings.ToLower(user.Username), strings.ToLower(strings.ReplaceAll(quiz.Label, " ", "-")))
+	// Level ini butuh role tertentu?
+	if reason := checkPrerequisites(cfg, catalog, quiz, i.Member); reason != "" {
+		RespondWithError(s, i, reason)
+		return
+	}
 
-	// Buat channel private
-	channel, err := s.GuildChannelCreateComplex(guildID, discordgo.GuildChannelCreateData{
-		Name:     channelName,
-		Type:     discordgo.ChannelTypeGuildText,
-		ParentID: quizCategoryID,
-		PermissionOverwrites: []*discordgo.PermissionOverwrite{
-			{
-				ID:   guildID, // semua user
-				Type: discordgo.PermissionOverwriteTypeRole,
-				Deny: discordgo.PermissionViewChannel,
-			},
-			{
-				ID:   user.ID, // user ini
-				Type: discordgo.PermissionOverwriteTypeMember,
-				Allow: discordgo.PermissionViewChannel |
-					discordgo.PermissionSendMessages,
-			},
-			{
-				ID:   kotobaBotID,
-				Type: discordgo.PermissionOverwriteTypeMember,
-				Allow: discordgo.PermissionViewChannel |
-					discordgo.PermissionSendMessages |
-					discordgo.PermissionReadMessageHistory,
-			},
-			{
-				ID:   s.State.User.ID,
-				Type: discordgo.PermissionOverwriteTypeMember,
-				Allow: discordgo.PermissionViewChannel |
-					discordgo.PermissionSendMessages |
-					discordgo.PermissionReadMessageHistory,
-			},
-		},
+	// Percobaan gagal sebelumnya masih dalam cooldown?
+	until, err := cooldownUntil(guildID, user.ID, quizID, quiz)
+	if err != nil {
+		log.Printf("Gagal membaca riwayat quiz %s: %v", user.ID, err)
+	} else if !until.IsZero() {
+		RespondWithError(s, i, fmt.Sprintf("Kamu baru saja gagal di **%s**. Kamu bisa mencoba lagi <t:%d:R> (<t:%d:t>).",
+			quiz.Label, until.Unix(), until.Unix()))
+		return
+	}
+
+	// Masih menunggu di antrian?
+	if pos := provisioner.Position(guildID, user.ID); pos > 0 {
+		RespondWithError(s, i, fmt.Sprintf("Kamu masih di antrian **#%d**. Tunggu giliranmu ya!", pos))
+		return
+	}
+
+	// Kunci user ini supaya klik ganda tidak membuat dua channel
+	if !sessions.Reserve(user.ID) {
+		RespondWithError(s, i, "Kamu sudah memiliki quiz aktif. Selesaikan dulu yang sebelumnya ya!")
+		return
+	}
+
+	// Channel dibuat di worker, jadi interaction dijawab dulu supaya tidak
+	// kedaluwarsa
+	deferEphemeral(s, i)
+
+	result, pos := provisioner.Submit(&provisionRequest{
+		s:           s,
+		interaction: i,
+		guildID:     guildID,
+		user:        user,
+		quizID:      quizID,
 	})
+	switch result {
+	case SubmitQueued:
+		followup(s, i, fmt.Sprintf("Semua slot quiz sedang terpakai. Kamu di antrian **#%d** untuk **%s**. "+
+			"Kamu akan di-mention dan dapat DM begitu channel-mu siap.", pos, quiz.Label))
+	case SubmitQueueFull:
+		sessions.Release(user.ID)
+		followup(s, i, "Antrian quiz sedang penuh. Coba lagi beberapa menit lagi ya!")
+	}
+}
+
+// provisionQuiz membuat channel atau thread quiz untuk request yang sudah
+// diterima Provisioner, lalu menyimpan sesi dan memberi tahu user.
+func provisionQuiz(req *provisionRequest) {
+	s, i, user := req.s, req.interaction, req.user
+
+	// Katalog atau konfigurasi bisa di-reload selama user menunggu
+	cfg, ok := GuildConfigFor(req.guildID)
+	if !ok {
+		log.Printf("Guild %s sudah tidak dikonfigurasi, quiz %s dibatalkan", req.guildID, user.ID)
+		failProvision(req, "Server ini belum dikonfigurasi untuk quiz.")
+		return
+	}
+	quiz, ok := CurrentCatalog().Quizzes[req.quizID]
+	if !ok {
+		log.Printf("Quiz %s sudah tidak ada di katalog, quiz %s dibatalkan", req.quizID, user.ID)
+		failProvision(req, "Quiz ini sudah tidak tersedia. Pilih level lain dari selector ya!")
+		return
+	}
+
+	channelName := fmt.Sprintf("quiz-%s-%s", strings.ToLower(user.Username), strings.ToLower(strings.ReplaceAll(quiz.Label, " ", "-")))
+
+	// Buat channel atau thread private sesuai mode guild
+	var channel *discordgo.Channel
+	var err error
+	if cfg.Mode() == QuizModeThread {
+		channel, err = createQuizThread(s, cfg, user.ID, channelName)
+	} else {
+		channel, err = createQuizChannel(s, cfg, req.guildID, user.ID, channelName)
+	}
 	if err != nil {
 		log.Printf("Gagal membuat channel private: %v", err)
-		RespondWithError(s, i, "Gagal membuat channel private!")
+		msg := "Gagal membuat channel private!"
+		if errors.Is(err, errQuizCategoriesFull) {
+			msg = "Semua kategori quiz sedang penuh. Coba lagi nanti atau hubungi moderator."
+		}
+		failProvision(req, msg)
 		return
 	}
 
 	// Simpan sesi quiz
-	activeQuizzes[user.ID] = QuizSession{
+	sessions.Create(QuizSession{
+		GuildID:   req.guildID,
 		UserID:    user.ID,
-		QuizID:    quizID,
+		QuizID:    req.quizID,
+		Quiz:      quiz,
 		ThreadID:  channel.ID,
 		ChannelID: i.ChannelID,
+		InThread:  channel.IsThread(),
 		Started:   false,
-	}
+		CreatedAt: time.Now(),
+	})
 
 	// Kirim pesan pembuka
-	commandsText := quiz.Commands[0]
+	commandsText := quiz.Stages[0].Command.String()
 	welcomeMsg := fmt.Sprintf(`Halo <@%s>! Untuk memulai quiz, copy dan paste command berikut:
 
 **Command:**
 `+"```"+
-		`%s`+"```"+`
+		`%s`+"```"+`%s
 
 **Cara bermain:**
 1. Copy command di atas
 2. Paste di channel ini
 3. Jawab pertanyaan dari Kotoba Bot
 4. Kamu akan mendapat role **%s** setelah menyelesaikan quiz!
-5. Kamu bisa hapus channel ini secara manual dengan a!del
+5. Kamu bisa hapus channel ini secara manual dengan /quiz close
 
 Jangan lupa paste command langsung di channel ini ya!`,
-		user.ID, commandsText, quiz.Label)
+		user.ID, commandsText, stageRules(quiz.Stages[0]), quiz.Label)
 
 	_, err = s.ChannelMessageSend(channel.ID, welcomeMsg)
 	if err != nil {
 		log.Printf("Gagal kirim pesan pembuka: %v", err)
 	}
 
-	// Respon interaction dengan followup ephemeral (bisa dihapus)
-	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
-	})
-	if err != nil {
-		log.Printf("Gagal merespons interaction: %v", err)
+	// User yang sempat antri mungkin sudah tidak melihat selector
+	if req.queued {
+		notifyQueuedUser(s, user.ID, fmt.Sprintf("Giliranmu tiba! Channel quiz **%s** sudah siap: <#%s>", quiz.Label, channel.ID))
 	}
 
 	msg, err := s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
 		Content: fmt.Sprintf("Channel private **%s** telah dibuat untuk quiz **%s**. Silakan lanjut di sana!", channel.Name, quiz.Label),
+		Flags:   discordgo.MessageFlagsEphemeral,
 	})
 	if err != nil {
 		log.Printf("Gagal kirim followup: %v", err)
@@ -149,56 +228,67 @@ Jangan lupa paste command langsung di channel ini ya!`,
 	}
 
 	// Hapus pesan setelah 10 detik
-	go func() {
-		time.Sleep(10 * time.Second)
+	go func(delay time.Duration) {
+		time.Sleep(delay)
 		err := s.FollowupMessageDelete(i.Interaction, msg.ID)
 		if err != nil {
 			log.Printf("Gagal hapus pesan followup: %v", err)
 		}
-	}()
+	}(followupDeleteDelay)
 }
 
-func OnMessageCreate(s *discordgo.Session, m *discordgo.MessageCreate) {
-	// Abaikan pesan bot (selain kotoba)
-	if m.Author.Bot && m.Author.ID != kotobaBotID {
+// failProvision melepas reservasi user dan memberi tahu kenapa tempat
+// quiz tidak dibuat.
+func failProvision(req *provisionRequest, msg string) {
+	sessions.Release(req.user.ID)
+	followup(req.s, req.interaction, msg)
+	if req.queued {
+		notifyQueuedUser(req.s, req.user.ID, msg)
+	}
+}
+
+// notifyQueuedUser mengirim DM ke user yang menunggu di antrian. Token
+// interaction-nya bisa sudah kedaluwarsa, jadi followup saja tidak cukup.
+func notifyQueuedUser(s Discord, userID, content string) {
+	dm, err := s.UserChannelCreate(userID)
+	if err != nil {
+		log.Printf("Gagal buka DM ke %s: %v", userID, err)
 		return
 	}
+	if _, err := s.ChannelMessageSend(dm.ID, content); err != nil {
+		log.Printf("Gagal kirim DM ke %s: %v", userID, err)
+	}
+}
 
-	if strings.HasPrefix(m.Content, "a!clear") {
-		HandleClearCommand(s, m)
+func OnMessageCreate(s Discord, m *discordgo.MessageCreate) {
+	// Abaikan pesan bot (selain kotoba)
+	if m.Author.Bot && m.Author.ID != kotobaBotID {
 		return
 	}
 
-	if strings.HasPrefix(m.Content, "a!del") {
-		channel, err := s.State.Channel(m.ChannelID)
-		if err != nil {
-			channel, err = s.Channel(m.ChannelID)
-			if err != nil {
-				log.Printf("Gagal mengambil channel: %v", err)
-				return
-			}
+	// Command prefix lama, hanya aktif kalau guild mengizinkan
+	if cfg, ok := GuildConfigFor(m.GuildID); ok && cfg.LegacyPrefixCommands && !m.Author.Bot {
+		if strings.HasPrefix(m.Content, "a!clear") {
+			HandleClearCommand(s, m)
+			return
+		}
+
+		if strings.HasPrefix(m.Content, "a!reload") {
+			HandleReloadCommand(s, m)
+			return
 		}
 
-		// Pastikan channel ini berada di kategori quiz
-		if channel.ParentID == quizCategoryID {
-			// Cegah penghapusan channel utama
-			if channel.ID == "1392463011301691442" {
-				s.ChannelMessageSend(m.ChannelID, "Channel ini adalah pusat selector quiz. Tidak bisa dihapus.")
+		if strings.HasPrefix(m.Content, "a!del") {
+			if reason := checkClosableQuizChannel(s, cfg, m.ChannelID); reason != "" {
+				s.ChannelMessageSend(m.ChannelID, reason)
 				return
 			}
 
 			// Kirim konfirmasi dan hapus
 			s.ChannelMessageSend(m.ChannelID, "Channel ini akan dihapus...")
-			time.Sleep(1 * time.Second)
-			_, err := s.ChannelDelete(m.ChannelID)
-			if err != nil {
-				log.Printf("Gagal hapus channel a!del: %v", err)
-			}
+			deleteQuizChannel(s, m.ChannelID)
 			return
 		}
-
-		s.ChannelMessageSend(m.ChannelID, "Channel ini bukan bagian dari kategori quiz.")
-		return
 	}
 
 	// Command quiz user biasa
@@ -213,171 +303,512 @@ func OnMessageCreate(s *discordgo.Session, m *discordgo.MessageCreate) {
 	}
 }
 
-func HandleUserCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
+// createQuizChannel membuat channel teks private di kategori quiz yang
+// masih punya slot. Channel hanya bisa dilihat user, Kotoba dan bot ini.
+func createQuizChannel(s Discord, cfg *GuildConfig, guildID, userID, name string) (*discordgo.Channel, error) {
+	categoryMu.Lock()
+	defer categoryMu.Unlock()
+
+	categoryID, err := pickQuizCategory(s, cfg, guildID)
+	if err != nil {
+		return nil, err
+	}
+	return s.GuildChannelCreateComplex(guildID, discordgo.GuildChannelCreateData{
+		Name:     name,
+		Type:     discordgo.ChannelTypeGuildText,
+		ParentID: categoryID,
+		PermissionOverwrites: []*discordgo.PermissionOverwrite{
+			{
+				ID:   guildID, // semua user
+				Type: discordgo.PermissionOverwriteTypeRole,
+				Deny: discordgo.PermissionViewChannel,
+			},
+			{
+				ID:   userID, // user ini
+				Type: discordgo.PermissionOverwriteTypeMember,
+				Allow: discordgo.PermissionViewChannel |
+					discordgo.PermissionSendMessages,
+			},
+			{
+				ID:   kotobaBotID,
+				Type: discordgo.PermissionOverwriteTypeMember,
+				Allow: discordgo.PermissionViewChannel |
+					discordgo.PermissionSendMessages |
+					discordgo.PermissionReadMessageHistory,
+			},
+			{
+				ID:   s.BotUser().ID,
+				Type: discordgo.PermissionOverwriteTypeMember,
+				Allow: discordgo.PermissionViewChannel |
+					discordgo.PermissionSendMessages |
+					discordgo.PermissionReadMessageHistory,
+			},
+		},
+	})
+}
+
+func HandleUserCommand(s Discord, m *discordgo.MessageCreate) {
 	if !strings.HasPrefix(m.Content, "k!quiz") {
 		return
 	}
 
-	session, exists := activeQuizzes[m.Author.ID]
+	session, exists := sessions.Get(m.Author.ID)
 	if !exists || m.ChannelID != session.ThreadID {
 		return
 	}
+	stage, ok := session.Quiz.Stage(session.Progress)
+	if !ok {
+		return
+	}
+
+	// "k!quiz stop" dan sejenisnya bukan command quiz baru
+	if fields := strings.Fields(m.Content); len(fields) < 2 || kotobaSubcommands[strings.ToLower(fields[1])] {
+		return
+	}
+
+	// Tahap ini baru saja gagal, cooldown level juga berlaku di channel ini
+	if until := session.RetryAfter; until.After(time.Now()) {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf(
+			"Kamu baru saja gagal di tahap ini, quiz ini **tidak akan dihitung**. Hentikan dengan `k!quiz stop` dan coba lagi <t:%d:R> (<t:%d:t>).",
+			until.Unix(), until.Unix()))
+		return
+	}
+
+	// Bandingkan command user dengan command tahap ini sebelum quiz dihitung
+	expectedCmd := stage.Command
+	actualCmd, err := ParseKotobaCommand(m.Content)
+	diffs := expectedCmd.Diff(actualCmd)
+	if err != nil {
+		diffs = []string{err.Error()}
+	}
+	if len(diffs) > 0 {
+		sessions.Update(m.Author.ID, func(sess *QuizSession) bool {
+			if !sess.Started {
+				return false
+			}
+			sess.Started = false
+			return true
+		})
+		s.ChannelMessageSend(m.ChannelID,
+			"Command tidak sesuai dengan quiz ini, quiz ini **tidak akan dihitung**:\n- "+strings.Join(diffs, "\n- ")+
+				"\nHentikan dengan `k!quiz stop` lalu paste command berikut:\n```"+expectedCmd.String()+"```")
+		return
+	}
 
 	// Tandai quiz dimulai
-	session.Started = true
-	activeQuizzes[m.Author.ID] = session
+	_, ok = sessions.Update(m.Author.ID, func(session *QuizSession) bool {
+		if m.ChannelID != session.ThreadID {
+			return false
+		}
+		session.Started = true
+		session.StageStartedAt = time.Now()
+		session.SettingsMismatch = nil
+		return true
+	})
+	if !ok {
+		return
+	}
 
 	// Kirim pesan konfirmasi sederhana
-	_, err := s.ChannelMessageSend(m.ChannelID, "Quiz dimulai! Tunggu Kotoba Bot untuk memberikan pertanyaan...")
+	_, err = s.ChannelMessageSend(m.ChannelID, "Quiz dimulai! Tunggu Kotoba Bot untuk memberikan pertanyaan...")
 	if err != nil {
 		log.Printf("Failed to send quiz start message: %v", err)
 	}
 }
 
-func HandleKotobaBotMessage(s *discordgo.Session, m *discordgo.MessageCreate) {
+func HandleKotobaBotMessage(s Discord, m *discordgo.MessageCreate) {
 	if len(m.Embeds) == 0 {
 		return
 	}
 
 	for _, embed := range m.Embeds {
-		if embed.Description == "" || !strings.Contains(embed.Description, "Congratulations!") {
+		if IsKotobaStartEmbed(embed) {
+			captureKotobaEmbed("start", embed)
+			handleKotobaStart(s, m, embed)
+			continue
+		}
+
+		result, ok := ParseKotobaResult(embed)
+		if !ok {
+			continue
+		}
+		captureKotobaEmbed(string(result.Outcome), embed)
+		switch result.Outcome {
+		case KotobaFailed, KotobaStopped:
+			handleKotobaFailure(s, m, embed, result)
+			continue
+		case KotobaUnknown:
+			log.Printf("Embed akhir Kotoba di channel %s tidak dikenali: %q", m.ChannelID, result.Reason)
+			handleKotobaUnknown(s, m, embed, result)
 			continue
 		}
 
 		// Temukan user dari session aktif
-		var userID string
-		var session QuizSession
-		for uid, sData := range activeQuizzes {
-			if sData.ThreadID == m.ChannelID && sData.Started {
-				userID = uid
-				session = sData
-				break
-			}
+		session, exists := sessions.GetByChannel(m.ChannelID)
+		if !exists || !session.Started {
+			return
 		}
-		if userID == "" {
+
+		// Ambil quiz info dan data validasi (definisi yang dipakai saat sesi dibuat)
+		stage, ok := session.Quiz.Stage(session.Progress)
+		if !ok {
 			return
 		}
 
-		// Ambil quiz info dan data validasi
-		quiz, ok := Quizzes[session.QuizID]
-		if !ok || session.Progress >= len(quiz.Commands) {
+		// Setiap hasil Kotoba dicatat ke riwayat, diterima atau tidak
+		reason := checkStageResult(s, m.GuildID, session, stage, result, embed)
+		recordStageResult(m.ChannelID, embed, result, reason)
+		if reason != "" {
+			s.ChannelMessageSend(session.ThreadID, reason)
 			return
 		}
 
-		expectedDeck := strings.ToLower(quiz.DeckNames[session.Progress])
-		expectedScore := strings.ToLower(quiz.ScoreLimits[session.Progress])
+		// ✅ Semua valid → lanjut
+		HandleMultiStageQuizCompletion(s, m)
+	}
+}
+
+// checkStageResult memeriksa hasil Kotoba yang selesai terhadap tahap sesi
+// saat ini. Hasilnya pesan penolakan untuk user, kosong kalau diterima.
+func checkStageResult(s Discord, guildID string, session QuizSession, stage Stage, result KotobaResult, embed *discordgo.MessageEmbed) string {
+	if result.ScoreLimit == 0 {
+		return "Command tidak sesuai sesi ini tidak dianggap. Silakan ulang dengan command yang sesuai."
+	}
 
-		// Ambil deck name dari title embed (contoh: "jpdb300 Ended")
-		titleDeck := strings.ToLower(strings.TrimSuffix(embed.Title, " Ended"))
+	if !strings.EqualFold(result.Deck, stage.ExpectedDeck()) || result.ScoreLimit != stage.ScoreLimit() {
+		return "Command tidak sesuai."
+	}
 
-		// Ambil score limit dari embed fields
-		scoreLine := ""
+	// Setting lain (hardcore, atl, mmq, ...) harus sama dengan command tahap ini
+	if diffs := stageSettingDiffs(session, ParseKotobaSettings(embed)); len(diffs) > 0 {
+		return "Setting quiz tidak sesuai dengan command, hasil tidak dihitung:\n- " + strings.Join(diffs, "\n- ") +
+			"\nSilakan ulang dengan command yang diberikan."
+	}
 
-		// Coba cari di embed.Fields
-		if embed.Fields != nil {
-			for _, f := range embed.Fields {
-				if strings.Contains(strings.ToLower(f.Name), "score limit") {
-					scoreLine = strings.ToLower(f.Value)
-					break
-				}
-			}
+	// Batas waktu dihitung sejak command tahap ini di-paste
+	if stage.TimeLimit.Duration > 0 && !session.StageStartedAt.IsZero() {
+		if elapsed := time.Since(session.StageStartedAt); elapsed > stage.TimeLimit.Duration {
+			return fmt.Sprintf("Quiz selesai dalam %s, melebihi batas waktu %s. Hasil tidak dihitung, silakan ulang quiz-nya.",
+				elapsed.Round(time.Second), stage.TimeLimit)
 		}
+	}
 
-		// Jika masih kosong, cari di embed.Description
-		if scoreLine == "" && strings.Contains(strings.ToLower(embed.Description), "score limit of") {
-			// Contoh: "The score limit of 10 was reached by @Ardya. Congratulations!"
-			desc := strings.ToLower(embed.Description)
-			idx := strings.Index(desc, "score limit of ")
-			if idx != -1 {
-				rest := desc[idx+len("score limit of "):]
-				// ambil angka sampai spasi berikutnya
-				scoreParts := strings.Fields(rest)
-				if len(scoreParts) > 0 {
-					scoreLine = scoreParts[0]
-				}
-			}
+	if reason := stage.CheckPass(result); reason != "" {
+		return "Syarat lulus tahap ini belum terpenuhi: " + reason + ". Hasil tidak dihitung, silakan ulang quiz-nya."
+	}
+
+	// Pastikan yang mencapai score limit adalah pemilik sesi
+	if ok, winner := isSessionWinner(s, guildID, session.UserID, result); !ok {
+		log.Printf("Hasil quiz di channel %s ditolak: pemenang %s bukan pemilik sesi %s", session.ThreadID, winner, session.UserID)
+		if winner == "" {
+			return "Tidak bisa memastikan siapa yang menyelesaikan quiz ini. Hasil tidak dihitung, silakan ulang quiz-nya."
 		}
+		return fmt.Sprintf("Quiz ini diselesaikan oleh %s, bukan oleh <@%s>. Hasil tidak dihitung, silakan ulang quiz-nya sendiri.", winner, session.UserID)
+	}
+	return ""
+}
 
-		// Ekstrak angka dari "scoreLine"
-		scoreParts := strings.Fields(scoreLine)
-		if len(scoreParts) == 0 {
-			s.ChannelMessageSend(session.ThreadID, "Command tidak sesuai sesi ini tidak dianggap. Silakan ulang dengan command yang sesuai.")
-			return
+// handleKotobaFailure menangani quiz yang berakhir tanpa mencapai score
+// limit (soal habis, terlalu banyak soal tidak terjawab, atau k!quiz stop).
+// Tahap saat ini diulang dan hasilnya dicatat di riwayat.
+func handleKotobaFailure(s Discord, m *discordgo.MessageCreate, embed *discordgo.MessageEmbed, result KotobaResult) {
+	session, exists := sessions.GetByChannel(m.ChannelID)
+	if !exists || !session.Started {
+		return
+	}
+	stage, ok := session.Quiz.Stage(session.Progress)
+	if !ok {
+		return
+	}
+
+	score := "?"
+	if n, ok := result.ScoreOf(session.UserID); ok {
+		score = strconv.Itoa(n)
+	}
+	progress := fmt.Sprintf("Skor kamu **%s/%d** di tahap %d dari %d.", score, stage.ScoreLimit(), session.Progress+1, len(session.Quiz.Stages))
+	if result.Questions > 0 {
+		progress += fmt.Sprintf(" Kotoba mencatat **%s soal**.", result.QuestionCount())
+		if result.QuestionsAtLeast {
+			progress += " Daftar soal tidak terjawab terpotong, jadi jumlah sebenarnya bisa lebih banyak."
 		}
-		actualScore := scoreParts[0]
+	}
 
-		if titleDeck != expectedDeck || actualScore != expectedScore {
-			s.ChannelMessageSend(session.ThreadID,
-				"Command tidak sesuai.",
-			)
-			return
+	reason := fmt.Sprintf("quiz berakhir di %s/%d poin", score, stage.ScoreLimit())
+	headline := "Quiz berakhir sebelum score limit tercapai."
+	if result.Outcome == KotobaStopped {
+		reason = fmt.Sprintf("quiz dihentikan di %s/%d poin", score, stage.ScoreLimit())
+		headline = "Quiz dihentikan."
+	}
+	recordStageResult(m.ChannelID, embed, result, reason)
+
+	// Cooldown level berlaku juga untuk mengulang di channel yang sama,
+	// kecuali quiz dihentikan untuk membetulkan setting yang berbeda
+	var retryAfter time.Time
+	if cooldown := session.Quiz.Cooldown.Duration; cooldown > 0 && (result.Outcome != KotobaStopped || len(session.SettingsMismatch) == 0) {
+		retryAfter = time.Now().Add(cooldown)
+	}
+
+	// Ulangi tahap ini dari awal
+	_, ok = sessions.Update(session.UserID, func(sess *QuizSession) bool {
+		if sess.ThreadID != m.ChannelID || !sess.Started {
+			return false
 		}
+		sess.Started = false
+		sess.StageStartedAt = time.Time{}
+		sess.SettingsMismatch = nil
+		sess.RetryAfter = retryAfter
+		return true
+	})
+	if !ok {
+		return
+	}
 
-		// ✅ Semua valid → lanjut
-		HandleMultiStageQuizCompletion(s, m)
+	retry := "\nSilakan coba lagi tahap ini dengan paste command berikut:"
+	if !retryAfter.IsZero() {
+		retry = fmt.Sprintf("\nKamu bisa mencoba lagi tahap ini <t:%d:R> (<t:%d:t>) dengan paste command berikut:", retryAfter.Unix(), retryAfter.Unix())
 	}
+	s.ChannelMessageSend(session.ThreadID, headline+" "+progress+retry+"\n```"+stage.Command.String()+"```")
 }
 
-func GetCurrentQuizRoleLevel(member *discordgo.Member) (int, string) {
-	for _, roleID := range member.Roles {
-		for _, quiz := range Quizzes {
-			if roleID == quiz.RoleID {
-				return quiz.Level, roleID
-			}
+// handleKotobaUnknown menangani embed akhir yang kalimatnya belum dikenal
+// parser. Hasilnya tidak dihitung, tapi user dan moderator diberi tahu
+// supaya sesi tidak diam menunggu.
+func handleKotobaUnknown(s Discord, m *discordgo.MessageCreate, embed *discordgo.MessageEmbed, result KotobaResult) {
+	session, exists := sessions.GetByChannel(m.ChannelID)
+	if !exists || !session.Started {
+		return
+	}
+	stage, ok := session.Quiz.Stage(session.Progress)
+	if !ok {
+		return
+	}
+	recordStageResult(m.ChannelID, embed, result, "embed akhir Kotoba tidak dikenali")
+
+	_, ok = sessions.Update(session.UserID, func(sess *QuizSession) bool {
+		if sess.ThreadID != m.ChannelID || !sess.Started {
+			return false
 		}
+		sess.Started = false
+		sess.StageStartedAt = time.Time{}
+		sess.SettingsMismatch = nil
+		return true
+	})
+	if !ok {
+		return
+	}
+
+	if cfg, ok := GuildConfigFor(session.GuildID); ok {
+		postModLog(s, cfg, modLogEvent{
+			Title:     "❓ Hasil Kotoba tidak dikenali",
+			Color:     modLogColorRejected,
+			UserID:    session.UserID,
+			Quiz:      &session.Quiz,
+			ResultURL: kotobaResultURL(m),
+			Reason:    result.Reason,
+		})
 	}
-	return -1, ""
+	s.ChannelMessageSend(session.ThreadID, "Bot tidak mengenali hasil quiz ini, jadi hasilnya **tidak dihitung**. Moderator sudah diberi tahu."+
+		"\nSilakan coba lagi tahap ini dengan paste command berikut:\n```"+stage.Command.String()+"```")
 }
 
-func HandleMultiStageQuizCompletion(s *discordgo.Session, m *discordgo.MessageCreate) {
-	var completedUserID string
-	var session QuizSession
+// handleKotobaStart mencatat setting yang diumumkan Kotoba saat quiz
+// dimulai. Kalau berbeda, user langsung diberi tahu supaya tidak
+// menghabiskan waktu untuk quiz yang tidak akan dihitung.
+func handleKotobaStart(s Discord, m *discordgo.MessageCreate, embed *discordgo.MessageEmbed) {
+	session, exists := sessions.GetByChannel(m.ChannelID)
+	if !exists || !session.Started {
+		return
+	}
+
+	diffs := stageSettingDiffs(session, ParseKotobaSettings(embed))
+	if len(diffs) == 0 {
+		return
+	}
 
-	for userID, sData := range activeQuizzes {
-		if sData.ThreadID == m.ChannelID && sData.Started {
-			completedUserID = userID
-			session = sData
-			break
+	sessions.Update(session.UserID, func(sess *QuizSession) bool {
+		sess.SettingsMismatch = diffs
+		return true
+	})
+	s.ChannelMessageSend(session.ThreadID,
+		"Setting quiz berbeda dengan command yang diberikan, hasil quiz ini tidak akan dihitung:\n- "+strings.Join(diffs, "\n- ")+
+			"\nHentikan dengan `k!quiz stop` lalu paste ulang command yang benar.")
+}
+
+// stageSettingDiffs membandingkan setting yang dilaporkan Kotoba dengan
+// command tahap sesi saat ini, ditambah perbedaan yang tercatat saat mulai.
+func stageSettingDiffs(session QuizSession, reported map[string]string) []string {
+	stage, ok := session.Quiz.Stage(session.Progress)
+	if !ok {
+		return nil
+	}
+	expected := stage.Command
+
+	diffs := append([]string(nil), session.SettingsMismatch...)
+	for _, d := range expected.CompareReported(reported) {
+		if !slices.Contains(diffs, d) {
+			diffs = append(diffs, d)
 		}
 	}
-	if completedUserID == "" {
-		return
+	return diffs
+}
+
+// stageRules menjelaskan batas waktu dan syarat lulus tambahan sebuah
+// tahap untuk pesan ke user. Kosong kalau tahap tidak punya aturan tambahan.
+func stageRules(st Stage) string {
+	var rules []string
+	if st.TimeLimit.Duration > 0 {
+		rules = append(rules, fmt.Sprintf("Batas waktu: **%s** sejak command di-paste", st.TimeLimit))
 	}
+	if st.Pass != nil && st.Pass.MaxQuestions > 0 {
+		rules = append(rules, fmt.Sprintf("Score limit harus tercapai dalam **%d soal**", st.Pass.MaxQuestions))
+	}
+	if len(rules) == 0 {
+		return ""
+	}
+	return "\n" + strings.Join(rules, "\n")
+}
 
-	quiz, ok := Quizzes[session.QuizID]
+// isSessionWinner cek apakah pemenang di embed Kotoba adalah pemilik sesi.
+// Hasil kedua adalah pemenang yang terbaca, untuk pesan penolakan.
+func isSessionWinner(s Discord, guildID, ownerID string, result KotobaResult) (bool, string) {
+	winner, ok := result.Winner()
 	if !ok {
-		return
+		return false, ""
+	}
+	if winner.UserID != "" {
+		return winner.UserID == ownerID, "<@" + winner.UserID + ">"
 	}
 
-	// Tandai sesi saat ini selesai
-	session.Started = false
+	// Versi lama Kotoba hanya menulis nama, cocokkan dengan nama member
+	member, err := s.GuildMember(guildID, ownerID)
+	if err != nil {
+		log.Printf("Gagal mendapatkan member %s: %v", ownerID, err)
+		return false, "**" + winner.Name + "**"
+	}
+	for _, name := range []string{member.Nick, member.User.GlobalName, member.User.Username} {
+		if name != "" && strings.EqualFold(name, winner.Name) {
+			return true, "**" + winner.Name + "**"
+		}
+	}
+	return false, "**" + winner.Name + "**"
+}
 
-	// === Masih ada command tahap selanjutnya?
-	if session.Progress+1 < len(quiz.Commands) {
-		session.Progress++
-		activeQuizzes[completedUserID] = session
+// HeldQuizRoles adalah role level quiz yang dipegang seorang member.
+type HeldQuizRoles struct {
+	Level  int      // level tertinggi, -1 kalau belum punya role quiz
+	RoleID string   // role level tertinggi
+	Roles  []string // semua role level yang dipegang, urut dari level terendah
+}
+
+// Lower mengembalikan role level yang lebih rendah dari level tertinggi.
+func (h HeldQuizRoles) Lower() []string {
+	if len(h.Roles) < 2 {
+		return nil
+	}
+	return h.Roles[:len(h.Roles)-1]
+}
+
+// GetCurrentQuizRoleLevel mengembalikan level tertinggi yang dipegang
+// member beserta semua role level miliknya. Urutan mengikuti level di
+// katalog, tidak bergantung pada urutan role member.
+func GetCurrentQuizRoleLevel(cfg *GuildConfig, member *discordgo.Member) HeldQuizRoles {
+	catalog := CurrentCatalog()
+	held := HeldQuizRoles{Level: -1}
+	for _, key := range catalog.Order {
+		quiz := catalog.Quizzes[key]
+		if r := cfg.RoleID(quiz); slices.Contains(member.Roles, r) {
+			held.Level, held.RoleID = quiz.Level, r
+			held.Roles = append(held.Roles, r)
+		}
+	}
+	return held
+}
+
+func HandleMultiStageQuizCompletion(s Discord, m *discordgo.MessageCreate) {
+	session, exists := sessions.GetByChannel(m.ChannelID)
+	if !exists || !session.Started {
+		return
+	}
+	completedUserID := session.UserID
+	quiz := session.Quiz
+
+	// Tandai sesi saat ini selesai. Dilakukan atomik supaya embed Kotoba
+	// yang terproses dua kali tidak melompati tahap.
+	finished := false
+	session, ok := sessions.Update(completedUserID, func(sess *QuizSession) bool {
+		if sess.ThreadID != m.ChannelID || !sess.Started {
+			return false
+		}
+		sess.Started = false
+		if sess.Progress+1 < len(quiz.Stages) {
+			sess.Progress++
+		} else {
+			finished = true
+		}
+		return true
+	})
+	if !ok {
+		return
+	}
 
-		nextCmd := quiz.Commands[session.Progress]
+	// === Masih ada command tahap selanjutnya?
+	if !finished {
+		next := quiz.Stages[session.Progress]
 		s.ChannelMessageSend(session.ThreadID,
-			"Sesi sebelumnya selesai! Sekarang lanjut ke quiz berikutnya:\n```"+nextCmd+"```")
+			"Sesi sebelumnya selesai! Sekarang lanjut ke quiz berikutnya:\n```"+next.Command.String()+"```"+stageRules(next))
 		return
 	}
 
 	// === Semua tahap selesai ===
 
+	cfg, ok := GuildConfigFor(m.GuildID)
+	if !ok {
+		log.Printf("Guild %s tidak punya konfigurasi quiz", m.GuildID)
+		return
+	}
+	roleID := cfg.RoleID(quiz)
+
 	// Dapatkan member info
 	member, err := s.GuildMember(m.GuildID, completedUserID)
 	if err != nil {
 		log.Printf("Gagal mendapatkan member: %v", err)
+		recordAttempt(session, AttemptError, "gagal mendapatkan data member: "+err.Error(), nil, nil)
 		return
 	}
 
-	currentLevel, currentRoleID := GetCurrentQuizRoleLevel(member)
+	held := GetCurrentQuizRoleLevel(cfg, member)
+	currentLevel := held.Level
+	resultURL := kotobaResultURL(m)
+
+	// Badge "lulus" diberikan untuk setiap level yang diselesaikan,
+	// termasuk level yang sama atau lebih rendah dari role sekarang
+	var added []string
+	badgeNote := ""
+	if badgeID := cfg.BadgeRoleID(quiz); badgeID != "" && !slices.Contains(member.Roles, badgeID) {
+		if err := s.GuildMemberRoleAdd(m.GuildID, completedUserID, badgeID); err != nil {
+			log.Printf("Gagal memberikan badge %s: %v", badgeID, err)
+			logRoleError(s, cfg, completedUserID, &quiz, "memberikan", badgeID, err)
+		} else {
+			added = append(added, badgeID)
+			badgeNote = fmt.Sprintf("\nBadge <@&%s> ditambahkan.", badgeID)
+		}
+	}
 
 	// CASE 1: Sudah punya role yang sama
 	if currentLevel == quiz.Level {
 		s.ChannelMessageSend(m.ChannelID,
-			fmt.Sprintf("Kamu sudah memiliki role **%s**. Tidak ada perubahan.\nChannel ini akan dihapus dalam 30 detik.", quiz.Label))
+			fmt.Sprintf("Kamu sudah memiliki role **%s**. Tidak ada perubahan.%s\nChannel ini akan dihapus dalam 30 detik.", quiz.Label, badgeNote))
+		recordAttempt(session, AttemptNoChange, "sudah memiliki role level yang sama", added, nil)
+		postModLog(s, cfg, modLogEvent{
+			Title:     "➖ Quiz selesai, role tidak berubah",
+			Color:     modLogColorNoChange,
+			UserID:    completedUserID,
+			Quiz:      &quiz,
+			OldRoles:  []string{held.RoleID},
+			NewRoles:  added,
+			ResultURL: resultURL,
+			Reason:    "sudah memiliki role level yang sama",
+		})
 		cleanupQuizChannel(s, completedUserID)
 		return
 	}
@@ -385,52 +816,125 @@ func HandleMultiStageQuizCompletion(s *discordgo.Session, m *discordgo.MessageCr
 	// CASE 2: Downgrade tidak diizinkan
 	if currentLevel > quiz.Level {
 		s.ChannelMessageSend(m.ChannelID,
-			"Kamu sudah memiliki role dengan level lebih tinggi. Downgrade tidak diizinkan.\nChannel ini akan dihapus dalam 30 detik.")
+			"Kamu sudah memiliki role dengan level lebih tinggi. Downgrade tidak diizinkan."+badgeNote+"\nChannel ini akan dihapus dalam 30 detik.")
+		reason := fmt.Sprintf("downgrade dari level %d tidak diizinkan", currentLevel)
+		recordAttempt(session, AttemptRejected, reason, added, nil)
+		postModLog(s, cfg, modLogEvent{
+			Title:     "⛔ Downgrade ditolak",
+			Color:     modLogColorRejected,
+			UserID:    completedUserID,
+			Quiz:      &quiz,
+			OldRoles:  []string{held.RoleID},
+			NewRoles:  added,
+			ResultURL: resultURL,
+			Reason:    reason,
+		})
 		cleanupQuizChannel(s, completedUserID)
 		return
 	}
 
-	// CASE 3: Upgrade role
-	if currentLevel >= 0 && currentRoleID != "" {
-		err := s.GuildMemberRoleRemove(m.GuildID, completedUserID, currentRoleID)
-		if err != nil {
-			log.Printf("Gagal menghapus role lama: %v", err)
-		}
-	}
-
-	err = s.GuildMemberRoleAdd(m.GuildID, completedUserID, quiz.RoleID)
+	// CASE 3: Upgrade role. Role baru diberikan dulu supaya member tidak
+	// kehilangan role lama kalau pemberian role gagal.
+	err = s.GuildMemberRoleAdd(m.GuildID, completedUserID, roleID)
 	if err != nil {
 		log.Printf("Gagal memberikan role baru: %v", err)
 		s.ChannelMessageSend(m.ChannelID, "Gagal memberikan role baru. Mohon hubungi admin.")
+		recordAttempt(session, AttemptError, "gagal memberikan role baru: "+err.Error(), added, nil)
+		logRoleError(s, cfg, completedUserID, &quiz, "memberikan", roleID, err)
 		return
 	}
+	added = append(added, roleID)
+
+	// Strategi cumulative menyimpan role lama; strategi lain mencabut
+	// semua role level lama, termasuk sisa role yang lebih rendah
+	var removed []string
+	if cfg.Strategy() != RoleCumulative {
+		for _, oldRoleID := range held.Roles {
+			if err := s.GuildMemberRoleRemove(m.GuildID, completedUserID, oldRoleID); err != nil {
+				log.Printf("Gagal menghapus role lama %s: %v", oldRoleID, err)
+				logRoleError(s, cfg, completedUserID, &quiz, "mencabut", oldRoleID, err)
+			} else {
+				removed = append(removed, oldRoleID)
+			}
+		}
+	}
+	recordAttempt(session, AttemptPassed, "", added, removed)
+	postModLog(s, cfg, modLogEvent{
+		Title:     "⬆️ Role naik level",
+		Color:     modLogColorUpgrade,
+		UserID:    completedUserID,
+		Quiz:      &quiz,
+		OldRoles:  removed, // hanya yang benar-benar dicabut, kosong untuk cumulative
+		NewRoles:  added,
+		ResultURL: resultURL,
+	})
 
 	// Sukses
 	s.ChannelMessageSend(m.ChannelID,
-		fmt.Sprintf("**SELAMAT** <@%s>! Kamu sekarang menjadi **%s**.\nChannel ini akan dihapus dalam 30 detik.", completedUserID, quiz.Label))
+		fmt.Sprintf("**SELAMAT** <@%s>! Kamu sekarang menjadi **%s**.%s\nChannel ini akan dihapus dalam 30 detik.", completedUserID, quiz.Label, badgeNote))
 
 	// Bersihkan channel dan sesi
 	cleanupQuizChannel(s, completedUserID)
 }
 
+// checkClosableQuizChannel memastikan channel boleh dihapus lewat a!del
+// atau /quiz close. Hasilnya alasan penolakan, kosong kalau boleh.
+func checkClosableQuizChannel(s Discord, cfg *GuildConfig, channelID string) string {
+	channel, err := s.Channel(channelID)
+	if err != nil {
+		log.Printf("Gagal mengambil channel: %v", err)
+		return "Gagal mengambil data channel."
+	}
+
+	// Thread di bawah selector hanya boleh ditutup kalau memang thread
+	// sesi quiz, bukan thread private lain yang dibuat member
+	if isQuizThread(cfg, channel) {
+		if _, ok := sessions.GetByChannel(channel.ID); !ok {
+			return "Thread ini bukan thread quiz yang aktif."
+		}
+		return ""
+	}
+
+	// Pastikan channel ini berada di kategori quiz
+	if !isQuizCategory(cfg, channel.GuildID, channel.ParentID) {
+		return "Channel ini bukan bagian dari kategori quiz."
+	}
+	// Cegah penghapusan channel utama
+	if channel.ID == cfg.SelectorChannelID {
+		return "Channel ini adalah pusat selector quiz. Tidak bisa dihapus."
+	}
+	return ""
+}
+
+// deleteQuizChannel melepas sesi yang terikat ke channel lalu menghapusnya
+func deleteQuizChannel(s Discord, channelID string) {
+	if session, ok := sessions.DeleteByChannel(channelID); ok {
+		recordAttempt(session, AttemptAbandoned, "channel ditutup sebelum quiz selesai", nil, nil)
+	}
+
+	time.Sleep(quizCloseDelay)
+	if err := closeQuizChannel(s, channelID); err != nil {
+		log.Printf("Gagal hapus channel quiz %s: %v", channelID, err)
+	}
+}
+
 // helper untuk bersihkan session, delete channel setelah delay
-func cleanupQuizChannel(s *discordgo.Session, userID string) {
-	session, exists := activeQuizzes[userID]
+func cleanupQuizChannel(s Discord, userID string) {
+	session, exists := sessions.Delete(userID)
 	if !exists {
 		return
 	}
-	delete(activeQuizzes, userID)
 
-	go func(chID string) {
-		time.Sleep(30 * time.Second)
-		if _, err := s.ChannelDelete(chID); err != nil {
+	go func(chID string, delay time.Duration) {
+		time.Sleep(delay)
+		if err := closeQuizChannel(s, chID); err != nil {
 			log.Printf("Gagal menghapus channel: %v", err)
 		}
-	}(session.ThreadID)
+	}(session.ThreadID, quizCleanupDelay)
 }
 
 // Background sweeper: delete inactive quiz channels (no activity for 24h)
-func StartInactiveChannelSweeper(s *discordgo.Session) {
+func StartInactiveChannelSweeper(s Discord) {
 	// Run once at start
 	go sweepInactiveQuizChannels(s)
 
@@ -444,25 +948,34 @@ func StartInactiveChannelSweeper(s *discordgo.Session) {
 	}()
 }
 
-func sweepInactiveQuizChannels(s *discordgo.Session) {
+func sweepInactiveQuizChannels(s Discord) {
 	threshold := time.Now().Add(-quizChannelTTL)
 
-	for _, g := range s.State.Guilds {
-		channels, err := s.GuildChannels(g.ID)
+	// Thread diarsipkan sendiri oleh Discord, cukup lepas sesinya
+	sweepArchivedQuizThreads(s)
+
+	for _, guildID := range s.GuildIDs() {
+		cfg, ok := GuildConfigFor(guildID)
+		if !ok {
+			continue
+		}
+
+		channels, err := s.GuildChannels(guildID)
 		if err != nil {
-			log.Printf("Gagal mengambil channel guild %s: %v", g.ID, err)
+			log.Printf("Gagal mengambil channel guild %s: %v", guildID, err)
 			continue
 		}
+		categories := quizCategorySet(cfg, guildID)
 
 		for _, ch := range channels {
 			if ch == nil || ch.Type != discordgo.ChannelTypeGuildText {
 				continue
 			}
-			if ch.ParentID != quizCategoryID {
+			if !categories[ch.ParentID] {
 				continue
 			}
 			// Skip selector channel
-			if ch.ID == "1392463011301691442" {
+			if ch.ID == cfg.SelectorChannelID {
 				continue
 			}
 
@@ -483,24 +996,24 @@ func sweepInactiveQuizChannels(s *discordgo.Session) {
 
 			if lastActivity.Before(threshold) {
 				// Remove any tracked session bound to this channel
-				for uid, sess := range activeQuizzes {
-					if sess.ThreadID == ch.ID {
-						delete(activeQuizzes, uid)
-						break
-					}
+				if session, ok := sessions.DeleteByChannel(ch.ID); ok {
+					recordAttempt(session, AttemptAbandoned, "channel tidak aktif", nil, nil)
 				}
 
-				if _, err := s.ChannelDelete(ch.ID); err != nil {
+				if err := closeQuizChannel(s, ch.ID); err != nil {
 					log.Printf("Gagal menghapus channel tidak aktif %s: %v", ch.ID, err)
 				} else {
 					log.Printf("Channel tidak aktif %s dihapus (last activity: %s)", ch.ID, lastActivity.Format(time.RFC3339))
 				}
 			}
 		}
+
+		removeEmptyOverflowCategories(s, cfg, guildID)
+		pruneTranscripts(cfg, guildID)
 	}
 }
 
-func RespondWithError(s *discordgo.Session, i *discordgo.InteractionCreate, message string) {
+func RespondWithError(s Discord, i *discordgo.InteractionCreate, message string) {
 	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseChannelMessageWithSource,
 		Data: &discordgo.InteractionResponseData{
@@ -0,0 +1,1163 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const testGuildID = "200000000000000000"
+
+// setupFlowTest memuat katalog bawaan repo dan konfigurasi guild test,
+// lalu menyiapkan fake guild dengan channel selector dan kategori quiz.
+func setupFlowTest(t *testing.T) (*fakeGuild, *GuildConfig) {
+	t.Helper()
+
+	guildConfigPath = filepath.Join("testdata", "guilds.json")
+	if _, err := ReloadQuizCatalog(); err != nil {
+		t.Fatalf("ReloadQuizCatalog: %v", err)
+	}
+	cfg, ok := GuildConfigFor(testGuildID)
+	if !ok {
+		t.Fatal("no guild config for test guild")
+	}
+
+	oldSessions, oldHistory, oldOverflow := sessions, history, overflowCategories
+	oldCleanup, oldClose, oldFollowup := quizCleanupDelay, quizCloseDelay, followupDeleteDelay
+	sessions = NewSessionManager(nil)
+	sessions.OnDelete(provisioner.SessionClosed)
+	history = NewFileHistoryStore(filepath.Join(t.TempDir(), "history.jsonl"))
+	overflowCategories = NewOverflowCategoryStore(filepath.Join(t.TempDir(), "overflow_categories.json"))
+	quizCleanupDelay, quizCloseDelay, followupDeleteDelay = 0, 0, 0
+	t.Cleanup(func() {
+		sessions, history, overflowCategories = oldSessions, oldHistory, oldOverflow
+		quizCleanupDelay, quizCloseDelay, followupDeleteDelay = oldCleanup, oldClose, oldFollowup
+	})
+
+	f := newFakeGuild(testGuildID)
+	f.addChannel(&discordgo.Channel{ID: cfg.QuizCategoryID, Type: discordgo.ChannelTypeGuildCategory})
+	f.addChannel(&discordgo.Channel{ID: cfg.SelectorChannelID, Type: discordgo.ChannelTypeGuildText, ParentID: cfg.QuizCategoryID})
+	return f, cfg
+}
+
+func quizRole(t *testing.T, cfg *GuildConfig, quizID string) string {
+	t.Helper()
+	quiz, ok := CurrentCatalog().Quizzes[quizID]
+	if !ok {
+		t.Fatalf("quiz %s not in catalog", quizID)
+	}
+	return cfg.RoleID(quiz)
+}
+
+// setCooldown mengaktifkan cooldown level di katalog test; quizzes.json
+// tidak memakai cooldown.
+func setCooldown(t *testing.T, quizID string, cooldown time.Duration) {
+	t.Helper()
+	catalog := CurrentCatalog()
+	quiz, ok := catalog.Quizzes[quizID]
+	if !ok {
+		t.Fatalf("quiz %s not in catalog", quizID)
+	}
+	quiz.Cooldown.Duration = cooldown
+	catalog.Quizzes[quizID] = quiz
+}
+
+func selectQuiz(s Discord, cfg *GuildConfig, userID, quizID string) {
+	// Interaction membawa role member saat itu, sama seperti dari Discord
+	member := &discordgo.Member{User: &discordgo.User{ID: userID, Username: userID}}
+	if m, err := s.GuildMember(testGuildID, userID); err == nil {
+		member = m
+	}
+	OnInteraction(s, &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+		ID:        "interaction-" + userID,
+		Type:      discordgo.InteractionMessageComponent,
+		GuildID:   testGuildID,
+		ChannelID: cfg.SelectorChannelID,
+		Member:    member,
+		Data: discordgo.MessageComponentInteractionData{
+			CustomID: "quiz_select",
+			Values:   []string{quizID},
+		},
+	}})
+	// Channel dibuat di worker Provisioner
+	provisioner.Wait()
+}
+
+func sendMessage(s Discord, channelID string, author *discordgo.User, content string, embeds ...*discordgo.MessageEmbed) {
+	OnMessageCreate(s, &discordgo.MessageCreate{Message: &discordgo.Message{
+		ID:        fmt.Sprintf("msg-%d", time.Now().UnixNano()),
+		GuildID:   testGuildID,
+		ChannelID: channelID,
+		Author:    author,
+		Content:   content,
+		Embeds:    embeds,
+	}})
+}
+
+// kotobaResultEmbed meniru embed hasil Kotoba ketika score limit tercapai
+func kotobaResultEmbed(deck, score, winnerID string) *discordgo.MessageEmbed {
+	return &discordgo.MessageEmbed{
+		Title:       deck + " Ended",
+		Description: fmt.Sprintf("The score limit of %s was reached by <@%s>. Congratulations!", score, winnerID),
+	}
+}
+
+// eventually menunggu kondisi yang dipenuhi goroutine cleanup
+func eventually(t *testing.T, cond func() bool) bool {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return true
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	return cond()
+}
+
+type kotobaRun struct {
+	deck  string
+	score string
+}
+
+func TestQuizFlow(t *testing.T) {
+	tests := []struct {
+		name        string
+		quizID      string
+		startRoles  []string // quiz ID yang sudah dimiliki member
+		runs        []kotobaRun
+		winner      string   // user yang mencapai score limit, kosong = pemilik sesi
+		nick        string   // nickname pemilik sesi
+		winnerName  string   // Kotoba hanya menulis nama pemenang (versi lama)
+		wantRoles   []string // quiz ID setelah flow selesai
+		wantReply   string
+		wantDeleted bool
+	}{
+		{
+			name:        "new member passes first level",
+			quizID:      "hiragana_katakana",
+			runs:        []kotobaRun{{"Multiple Deck Quiz", "10"}},
+			wantRoles:   []string{"hiragana_katakana"},
+			wantReply:   "**SELAMAT**",
+			wantDeleted: true,
+		},
+		{
+			name:        "upgrade replaces previous role",
+			quizID:      "Level_2",
+			startRoles:  []string{"Level_1"},
+			runs:        []kotobaRun{{"jpdb300to1k", "25"}},
+			wantRoles:   []string{"Level_2"},
+			wantReply:   "**SELAMAT**",
+			wantDeleted: true,
+		},
+		{
+			name:        "same level is a no-op",
+			quizID:      "Level_1",
+			startRoles:  []string{"Level_1"},
+			runs:        []kotobaRun{{"jpdb300", "20"}},
+			wantRoles:   []string{"Level_1"},
+			wantReply:   "Tidak ada perubahan",
+			wantDeleted: true,
+		},
+		{
+			name:        "downgrade is rejected",
+			quizID:      "Level_1",
+			startRoles:  []string{"Level_3"},
+			runs:        []kotobaRun{{"jpdb300", "20"}},
+			wantRoles:   []string{"Level_3"},
+			wantReply:   "Downgrade tidak diizinkan",
+			wantDeleted: true,
+		},
+		{
+			name:       "multi stage level needs every stage",
+			quizID:     "Level_4",
+			startRoles: []string{"Level_3"},
+			runs: []kotobaRun{
+				{"JLPT N2 Grammar Quiz", "20"},
+				{"jpdb3k5k", "35"},
+			},
+			wantRoles:   []string{"Level_4"},
+			wantReply:   "**SELAMAT**",
+			wantDeleted: true,
+		},
+		{
+			name:        "wrong deck is not counted",
+			quizID:      "Level_1",
+			runs:        []kotobaRun{{"jpdb300to1k", "20"}},
+			wantReply:   "Command tidak sesuai.",
+			wantRoles:   nil,
+			wantDeleted: false,
+		},
+		{
+			name:        "another member's win is rejected",
+			quizID:      "Level_1",
+			runs:        []kotobaRun{{"jpdb300", "20"}},
+			winner:      "100000000000000002",
+			wantReply:   "bukan oleh <@100000000000000001>",
+			wantRoles:   nil,
+			wantDeleted: false,
+		},
+		{
+			name:        "winner matched by nickname",
+			quizID:      "Level_1",
+			runs:        []kotobaRun{{"jpdb300", "20"}},
+			nick:        "john.doe",
+			winnerName:  "john.doe",
+			wantRoles:   []string{"Level_1"},
+			wantReply:   "**SELAMAT**",
+			wantDeleted: true,
+		},
+		{
+			name:        "another name is rejected",
+			quizID:      "Level_1",
+			runs:        []kotobaRun{{"jpdb300", "20"}},
+			nick:        "john.doe",
+			winnerName:  "john",
+			wantReply:   "diselesaikan oleh **john**",
+			wantRoles:   nil,
+			wantDeleted: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, cfg := setupFlowTest(t)
+			const userID = "100000000000000001"
+
+			var startRoles []string
+			for _, q := range tt.startRoles {
+				startRoles = append(startRoles, quizRole(t, cfg, q))
+			}
+			f.addMember(userID, startRoles...)
+			if tt.nick != "" {
+				f.setNick(userID, tt.nick)
+			}
+			user := &discordgo.User{ID: userID, Username: userID}
+			kotoba := &discordgo.User{ID: kotobaBotID, Username: "Kotoba", Bot: true}
+
+			// Pilih quiz → channel private dibuat
+			selectQuiz(f, cfg, userID, tt.quizID)
+			session, ok := sessions.Get(userID)
+			if !ok {
+				t.Fatalf("no session after selecting quiz; reply %q", f.lastResponse())
+			}
+			channelID := session.ThreadID
+			if !f.hasChannel(channelID) {
+				t.Fatalf("quiz channel %s was not created", channelID)
+			}
+
+			quiz := CurrentCatalog().Quizzes[tt.quizID]
+			for stage, run := range tt.runs {
+				// User paste command → Kotoba selesai dengan embed hasil
+				sendMessage(f, channelID, user, quiz.Stages[stage].Command.String())
+				if s, _ := sessions.Get(userID); !s.Started {
+					t.Fatalf("stage %d: session not started after k!quiz", stage)
+				}
+				winner := tt.winner
+				if winner == "" {
+					winner = userID
+				}
+				embed := kotobaResultEmbed(run.deck, run.score, winner)
+				if tt.winnerName != "" {
+					embed.Description = fmt.Sprintf("The score limit of %s was reached by @%s. Congratulations!", run.score, tt.winnerName)
+				}
+				sendMessage(f, channelID, kotoba, "", embed)
+			}
+
+			if got := f.lastMessage(channelID); tt.wantReply != "" && !strings.Contains(got, tt.wantReply) {
+				t.Errorf("last message = %q, want it to contain %q", got, tt.wantReply)
+			}
+
+			var wantRoles []string
+			for _, q := range tt.wantRoles {
+				wantRoles = append(wantRoles, quizRole(t, cfg, q))
+			}
+			sort.Strings(wantRoles)
+			if got := f.memberRoles(userID); !reflect.DeepEqual(got, wantRoles) {
+				t.Errorf("roles = %v, want %v", got, wantRoles)
+			}
+
+			if tt.wantDeleted {
+				if !eventually(t, func() bool { return !f.hasChannel(channelID) }) {
+					t.Error("quiz channel was not deleted")
+				}
+				if _, ok := sessions.Get(userID); ok {
+					t.Error("session survived cleanup")
+				}
+			} else if !f.hasChannel(channelID) {
+				t.Error("quiz channel was deleted")
+			}
+		})
+	}
+}
+
+func TestQuizSelectRejectsSecondSession(t *testing.T) {
+	f, cfg := setupFlowTest(t)
+	f.addMember("100000000000000001")
+
+	selectQuiz(f, cfg, "100000000000000001", "Level_1")
+	selectQuiz(f, cfg, "100000000000000001", "Level_2")
+
+	if got := len(f.channelsUnder(cfg.QuizCategoryID)); got != 2 { // selector + satu channel quiz
+		t.Fatalf("channels under quiz category = %d, want 2", got)
+	}
+	if got := f.lastResponse(); !strings.Contains(got, "sudah memiliki quiz aktif") {
+		t.Errorf("response = %q", got)
+	}
+}
+
+func TestQuizCloseCommand(t *testing.T) {
+	f, cfg := setupFlowTest(t)
+	f.addMember("100000000000000001")
+
+	selectQuiz(f, cfg, "100000000000000001", "Level_1")
+	session, _ := sessions.Get("100000000000000001")
+
+	OnInteraction(f, &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+		Type:      discordgo.InteractionApplicationCommand,
+		GuildID:   testGuildID,
+		ChannelID: session.ThreadID,
+		Member:    &discordgo.Member{User: &discordgo.User{ID: "100000000000000001"}},
+		Data: discordgo.ApplicationCommandInteractionData{
+			Name: "quiz",
+			Options: []*discordgo.ApplicationCommandInteractionDataOption{
+				{Name: "close", Type: discordgo.ApplicationCommandOptionSubCommand},
+			},
+		},
+	}})
+
+	if f.hasChannel(session.ThreadID) {
+		t.Error("quiz channel was not deleted")
+	}
+	if _, ok := sessions.Get("100000000000000001"); ok {
+		t.Error("session survived /quiz close")
+	}
+}
+
+func TestEasierSettingsAreRejected(t *testing.T) {
+	f, cfg := setupFlowTest(t)
+	const userID = "100000000000000001"
+	f.addMember(userID)
+	user := &discordgo.User{ID: userID, Username: userID}
+	kotoba := &discordgo.User{ID: kotobaBotID, Username: "Kotoba", Bot: true}
+
+	selectQuiz(f, cfg, userID, "Level_1")
+	session, _ := sessions.Get(userID)
+
+	// Command benar, tapi Kotoba mengumumkan quiz tanpa hardcore dengan
+	// waktu jawab lebih lama (mis. command diedit setelah dikirim)
+	sendMessage(f, session.ThreadID, user, session.Quiz.Stages[0].Command.String())
+	sendMessage(f, session.ThreadID, kotoba, "", &discordgo.MessageEmbed{
+		Title: "Starting quiz in 5 seconds",
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Score limit", Value: "20"},
+			{Name: "Answer time limit", Value: "30 seconds"},
+			{Name: "Hardcore mode", Value: "No"},
+		},
+	})
+	if got := f.lastMessage(session.ThreadID); !strings.Contains(got, "atl: seharusnya 16") {
+		t.Fatalf("start warning = %q", got)
+	}
+
+	sendMessage(f, session.ThreadID, kotoba, "", kotobaResultEmbed("jpdb300", "20", userID))
+	if got := f.lastMessage(session.ThreadID); !strings.Contains(got, "hasil tidak dihitung") {
+		t.Errorf("result reply = %q", got)
+	}
+	if roles := f.memberRoles(userID); len(roles) != 0 {
+		t.Errorf("roles = %v, want none", roles)
+	}
+}
+
+// Berhenti karena diminta membetulkan setting bukan kegagalan, jadi tidak
+// terkena cooldown
+func TestStopAfterSettingsWarningHasNoCooldown(t *testing.T) {
+	f, cfg := setupFlowTest(t)
+	const userID = "100000000000000001"
+	f.addMember(userID)
+	setCooldown(t, "Level_1", 15*time.Minute)
+	user := &discordgo.User{ID: userID, Username: userID}
+	kotoba := &discordgo.User{ID: kotobaBotID, Username: "Kotoba", Bot: true}
+
+	selectQuiz(f, cfg, userID, "Level_1")
+	session, _ := sessions.Get(userID)
+	command := session.Quiz.Stages[0].Command.String()
+
+	sendMessage(f, session.ThreadID, user, command)
+	sendMessage(f, session.ThreadID, kotoba, "", &discordgo.MessageEmbed{
+		Title: "Starting quiz in 5 seconds",
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Score limit", Value: "20"},
+			{Name: "Hardcore mode", Value: "No"},
+		},
+	})
+	sendMessage(f, session.ThreadID, kotoba, "", &discordgo.MessageEmbed{
+		Title:       "jpdb300 Ended",
+		Description: "<@" + userID + "> asked me to stop the quiz.",
+		Fields:      []*discordgo.MessageEmbedField{{Name: "Final Scores", Value: "<@" + userID + "> has 0 points"}},
+	})
+	if got, _ := sessions.Get(userID); !got.RetryAfter.IsZero() {
+		t.Fatalf("retryAfter = %v, want no cooldown", got.RetryAfter)
+	}
+
+	sendMessage(f, session.ThreadID, user, command)
+	if got, _ := sessions.Get(userID); !got.Started {
+		t.Errorf("retry not started: %q", f.lastMessage(session.ThreadID))
+	}
+}
+
+func TestWrongCommandIsNotStarted(t *testing.T) {
+	f, cfg := setupFlowTest(t)
+	const userID = "100000000000000001"
+	f.addMember(userID)
+	user := &discordgo.User{ID: userID, Username: userID}
+
+	selectQuiz(f, cfg, userID, "Level_1")
+	session, _ := sessions.Get(userID)
+
+	sendMessage(f, session.ThreadID, user, "k!quiz jpdb300 20 nd mmq=10 dauq=1 font=5 atl=30 color=#f173ff size=100")
+	if s, _ := sessions.Get(userID); s.Started {
+		t.Fatal("session started with a wrong command")
+	}
+	got := f.lastMessage(session.ThreadID)
+	for _, want := range []string{"hardcore: tidak ada", "atl: seharusnya 16, tertulis 30", "effect: tidak ada"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("reply %q does not mention %q", got, want)
+		}
+	}
+
+	// k!quiz stop tidak dianggap command quiz
+	sendMessage(f, session.ThreadID, user, "k!quiz stop")
+	if f.lastMessage(session.ThreadID) != got {
+		t.Error("k!quiz stop produced a reply")
+	}
+
+	sendMessage(f, session.ThreadID, user, session.Quiz.Stages[0].Command.String())
+	if s, _ := sessions.Get(userID); !s.Started {
+		t.Fatal("session not started with the expected command")
+	}
+}
+
+func TestStageRules(t *testing.T) {
+	f, cfg := setupFlowTest(t)
+	const userID = "100000000000000001"
+	f.addMember(userID)
+	user := &discordgo.User{ID: userID, Username: userID}
+	kotoba := &discordgo.User{ID: kotobaBotID, Username: "Kotoba", Bot: true}
+
+	selectQuiz(f, cfg, userID, "Level_1")
+	sessions.Update(userID, func(sess *QuizSession) bool {
+		// Salin supaya tahap di katalog tidak ikut berubah
+		sess.Quiz.Stages = append([]Stage(nil), sess.Quiz.Stages...)
+		sess.Quiz.Stages[0].TimeLimit = Duration{10 * time.Minute}
+		sess.Quiz.Stages[0].Pass = &PassCriteria{MaxQuestions: 25}
+		return true
+	})
+	session, _ := sessions.Get(userID)
+	result := kotobaResultEmbed("jpdb300", "20", userID)
+	result.Fields = []*discordgo.MessageEmbedField{
+		{Name: "Final Scores", Value: "<@" + userID + "> has 20 points"},
+		{Name: "Unanswered Questions", Value: "一\n二\n三\n四\n五\n六"},
+	}
+
+	// 26 soal untuk 20 poin melebihi maxQuestions
+	sendMessage(f, session.ThreadID, user, session.Quiz.Stages[0].Command.String())
+	sendMessage(f, session.ThreadID, kotoba, "", result)
+	if got := f.lastMessage(session.ThreadID); !strings.Contains(got, "dalam 25 soal") {
+		t.Errorf("last message = %q, want pass criteria rejection", got)
+	}
+
+	// Selesai setelah batas waktu
+	sessions.Update(userID, func(sess *QuizSession) bool {
+		sess.StageStartedAt = time.Now().Add(-11 * time.Minute)
+		return true
+	})
+	sendMessage(f, session.ThreadID, kotoba, "", kotobaResultEmbed("jpdb300", "20", userID))
+	if got := f.lastMessage(session.ThreadID); !strings.Contains(got, "melebihi batas waktu 10m0s") {
+		t.Errorf("last message = %q, want time limit rejection", got)
+	}
+	if len(f.memberRoles(userID)) != 0 {
+		t.Error("role given despite failed stage rules")
+	}
+}
+
+func TestQuizHistory(t *testing.T) {
+	f, cfg := setupFlowTest(t)
+	const userID = "100000000000000001"
+	const modID = "100000000000000009"
+	f.addMember(userID)
+	f.addMember(modID, cfg.ModeratorRoles[0])
+	user := &discordgo.User{ID: userID, Username: userID}
+	kotoba := &discordgo.User{ID: kotobaBotID, Username: "Kotoba", Bot: true}
+
+	// Satu hasil ditolak (deck salah), lalu lulus
+	selectQuiz(f, cfg, userID, "Level_1")
+	session, _ := sessions.Get(userID)
+	sendMessage(f, session.ThreadID, user, session.Quiz.Stages[0].Command.String())
+	sendMessage(f, session.ThreadID, kotoba, "", kotobaResultEmbed("jpdb300to1k", "20", userID))
+	sendMessage(f, session.ThreadID, kotoba, "", kotobaResultEmbed("jpdb300", "20", userID))
+
+	attempts, err := history.ForUser(testGuildID, userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(attempts) != 1 {
+		t.Fatalf("attempts = %d, want 1", len(attempts))
+	}
+	a := attempts[0]
+	if a.Outcome != AttemptPassed || a.QuizID != "Level_1" || len(a.Stages) != 2 {
+		t.Fatalf("attempt = %+v", a)
+	}
+	if a.Stages[0].Passed || !a.Stages[1].Passed || a.Stages[1].Embed == nil {
+		t.Errorf("stages = %+v", a.Stages)
+	}
+	if want := []string{quizRole(t, cfg, "Level_1")}; !reflect.DeepEqual(a.RolesAdded, want) {
+		t.Errorf("rolesAdded = %v, want %v", a.RolesAdded, want)
+	}
+
+	OnInteraction(f, &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+		Type:    discordgo.InteractionApplicationCommand,
+		GuildID: testGuildID,
+		Member:  &discordgo.Member{User: &discordgo.User{ID: modID}, Roles: []string{cfg.ModeratorRoles[0]}},
+		Data: discordgo.ApplicationCommandInteractionData{
+			Name: "quiz",
+			Options: []*discordgo.ApplicationCommandInteractionDataOption{{
+				Name: "history",
+				Type: discordgo.ApplicationCommandOptionSubCommand,
+				Options: []*discordgo.ApplicationCommandInteractionDataOption{
+					{Name: "user", Type: discordgo.ApplicationCommandOptionUser, Value: userID},
+				},
+			}},
+		},
+	}})
+
+	embed := f.lastEmbed()
+	if embed == nil || len(embed.Fields) != 1 {
+		t.Fatalf("history embed = %+v", embed)
+	}
+	if got := embed.Fields[0].Value; !strings.Contains(got, "lulus") || !strings.Contains(got, "❌ Tahap 1") {
+		t.Errorf("history field = %q", got)
+	}
+}
+
+func TestCooldownAfterAbandonedAttempt(t *testing.T) {
+	f, cfg := setupFlowTest(t)
+	const userID = "100000000000000001"
+	f.addMember(userID)
+	setCooldown(t, "Level_1", 15*time.Minute)
+	setCooldown(t, "Level_2", 15*time.Minute)
+
+	selectQuiz(f, cfg, userID, "Level_1")
+	session, _ := sessions.Get(userID)
+	deleteQuizChannel(f, session.ThreadID)
+
+	selectQuiz(f, cfg, userID, "Level_1")
+	if _, ok := sessions.Get(userID); ok {
+		t.Fatal("session created during cooldown")
+	}
+	if got := f.lastResponse(); !strings.Contains(got, "bisa mencoba lagi <t:") {
+		t.Errorf("response = %q", got)
+	}
+	if got := len(f.channelsUnder(cfg.QuizCategoryID)); got != 1 { // hanya selector
+		t.Errorf("channels under quiz category = %d, want 1", got)
+	}
+
+	// Level lain tidak terkena cooldown
+	selectQuiz(f, cfg, userID, "Level_2")
+	session, ok := sessions.Get(userID)
+	if !ok {
+		t.Fatalf("no session for another level; reply %q", f.lastResponse())
+	}
+	deleteQuizChannel(f, session.ThreadID)
+
+	// Percobaan yang sudah lewat cooldown tidak menghalangi
+	cooldown := CurrentCatalog().Quizzes["Level_1"].Cooldown.Duration
+	history.Append(Attempt{GuildID: testGuildID, UserID: "100000000000000003", QuizID: "Level_1", Outcome: AttemptAbandoned, EndedAt: time.Now().Add(-cooldown - time.Minute)})
+	f.addMember("100000000000000003")
+	selectQuiz(f, cfg, "100000000000000003", "Level_1")
+	if _, ok := sessions.Get("100000000000000003"); !ok {
+		t.Errorf("expired cooldown still blocks; reply %q", f.lastResponse())
+	}
+}
+
+func TestFailedRunResetsStage(t *testing.T) {
+	f, cfg := setupFlowTest(t)
+	const userID = "100000000000000001"
+	f.addMember(userID, quizRole(t, cfg, "Level_3"))
+	setCooldown(t, "Level_4", 15*time.Minute)
+	user := &discordgo.User{ID: userID, Username: userID}
+	kotoba := &discordgo.User{ID: kotobaBotID, Username: "Kotoba", Bot: true}
+
+	selectQuiz(f, cfg, userID, "Level_4")
+	session, _ := sessions.Get(userID)
+	stages := session.Quiz.Stages
+
+	sendMessage(f, session.ThreadID, user, stages[0].Command.String())
+	sendMessage(f, session.ThreadID, kotoba, "", kotobaResultEmbed("JLPT N2 Grammar Quiz", "20", userID))
+
+	for _, ending := range []struct {
+		description string
+		unanswered  string
+		want        string
+	}{
+		// Daftar soal tidak terjawab terpotong, jumlah soal hanya batas bawah
+		{"Too many unanswered questions in a row. Stopping.", "一\n二\n三…",
+			"Quiz berakhir sebelum score limit tercapai. Skor kamu **12/35** di tahap 2 dari 2. Kotoba mencatat **minimal 14 soal**."},
+		{"<@" + userID + "> asked me to stop the quiz.", "",
+			"Quiz dihentikan. Skor kamu **12/35** di tahap 2 dari 2. Kotoba mencatat **12 soal**."},
+	} {
+		fields := []*discordgo.MessageEmbedField{{Name: "Final Scores", Value: "<@" + userID + "> has 12 points"}}
+		if ending.unanswered != "" {
+			fields = append(fields, &discordgo.MessageEmbedField{Name: "Unanswered Questions", Value: ending.unanswered})
+		}
+		sendMessage(f, session.ThreadID, user, stages[1].Command.String())
+		sendMessage(f, session.ThreadID, kotoba, "", &discordgo.MessageEmbed{
+			Title:       "jpdb3k5k Ended",
+			Description: ending.description,
+			Fields:      fields,
+		})
+
+		got, _ := sessions.Get(userID)
+		if got.Started || got.Progress != 1 {
+			t.Fatalf("after %q: started=%v progress=%d, want stage 2 reset", ending.description, got.Started, got.Progress)
+		}
+		if msg := f.lastMessage(session.ThreadID); !strings.Contains(msg, ending.want) || !strings.Contains(msg, stages[1].Command.String()) {
+			t.Errorf("message = %q, want %q and the stage command", msg, ending.want)
+		}
+
+		// Cooldown level juga berlaku untuk mengulang di channel yang sama
+		if !got.RetryAfter.After(time.Now()) {
+			t.Fatalf("after %q: no cooldown, retryAfter=%v", ending.description, got.RetryAfter)
+		}
+		sendMessage(f, session.ThreadID, user, stages[1].Command.String())
+		if got, _ := sessions.Get(userID); got.Started {
+			t.Fatalf("after %q: retry started during cooldown", ending.description)
+		}
+		if msg := f.lastMessage(session.ThreadID); !strings.Contains(msg, "coba lagi <t:") {
+			t.Errorf("retry message = %q", msg)
+		}
+		sessions.Update(userID, func(sess *QuizSession) bool {
+			sess.RetryAfter = time.Now().Add(-time.Second)
+			return true
+		})
+	}
+
+	// Percobaan ulang tetap bisa lulus
+	sendMessage(f, session.ThreadID, user, stages[1].Command.String())
+	sendMessage(f, session.ThreadID, kotoba, "", kotobaResultEmbed("jpdb3k5k", "35", userID))
+	if got := f.lastMessage(session.ThreadID); !strings.Contains(got, "**SELAMAT**") {
+		t.Fatalf("last message = %q", got)
+	}
+	// Tunggu channel ditutup supaya goroutine cleanup tidak membaca
+	// konfigurasi test berikutnya
+	if !eventually(t, func() bool { return !f.hasChannel(session.ThreadID) }) {
+		t.Error("quiz channel was not deleted")
+	}
+
+	attempts, _ := history.ForUser(testGuildID, userID)
+	if len(attempts) != 1 || len(attempts[0].Stages) != 4 {
+		t.Fatalf("attempts = %+v", attempts)
+	}
+	if s := attempts[0].Stages[1]; s.Passed || s.Stage != 1 || s.Reason != "quiz berakhir di 12/35 poin" {
+		t.Errorf("failed stage = %+v", s)
+	}
+}
+
+func TestUnknownResultIsReported(t *testing.T) {
+	f, cfg := setupFlowTest(t)
+	const userID = "100000000000000001"
+	f.addChannel(&discordgo.Channel{ID: "mod-log", Type: discordgo.ChannelTypeGuildText})
+	cfg.ModLogChannelID = "mod-log"
+	f.addMember(userID)
+	user := &discordgo.User{ID: userID, Username: userID}
+	kotoba := &discordgo.User{ID: kotobaBotID, Username: "Kotoba", Bot: true}
+
+	selectQuiz(f, cfg, userID, "Level_1")
+	session, _ := sessions.Get(userID)
+	sendMessage(f, session.ThreadID, user, session.Quiz.Stages[0].Command.String())
+	sendMessage(f, session.ThreadID, kotoba, "", &discordgo.MessageEmbed{
+		Title:       "jpdb300 Ended",
+		Description: "The quiz timed out.",
+		Fields:      []*discordgo.MessageEmbedField{{Name: "Final Scores", Value: "<@" + userID + "> has 7 points"}},
+	})
+
+	got, _ := sessions.Get(userID)
+	if got.Started {
+		t.Error("session still waiting after an unrecognised result")
+	}
+	if msg := f.lastMessage(session.ThreadID); !strings.Contains(msg, "tidak mengenali hasil quiz") {
+		t.Errorf("message = %q", msg)
+	}
+	if embeds := modLogEmbeds(f); len(embeds) != 1 || embedField(embeds[0], "Alasan") != "The quiz timed out." {
+		t.Errorf("mod-log = %+v", embeds)
+	}
+	if len(got.StageLog) != 1 || got.StageLog[0].Passed {
+		t.Errorf("stage log = %+v", got.StageLog)
+	}
+}
+
+func TestQuizPrerequisites(t *testing.T) {
+	f, cfg := setupFlowTest(t)
+	const newcomer = "100000000000000001"
+	const climber = "100000000000000002"
+	f.addMember(newcomer)
+	f.addMember(climber, quizRole(t, cfg, "Level_1"))
+
+	// Prasyarat opsional: katalog bawaan tidak mensyaratkan apa-apa
+	selectQuiz(f, cfg, newcomer, "Level_7")
+	if _, ok := sessions.Delete(newcomer); !ok {
+		t.Fatalf("newcomer blocked without prerequisites; reply %q", f.lastResponse())
+	}
+
+	// Level_7 mensyaratkan minimal level 6
+	minLevel := 6
+	catalog := CurrentCatalog()
+	quiz := catalog.Quizzes["Level_7"]
+	quiz.Requires = &Prerequisite{MinLevel: &minLevel}
+	catalog.Quizzes["Level_7"] = quiz
+	selectQuiz(f, cfg, newcomer, "Level_7")
+	if _, ok := sessions.Get(newcomer); ok {
+		t.Fatal("newcomer got a Level_7 session")
+	}
+	if got := f.lastResponse(); !strings.Contains(got, "<@&"+quizRole(t, cfg, "Level_6")+">") {
+		t.Errorf("response = %q, want it to name the Level_6 role", got)
+	}
+
+	cfg.StrictLadder = true
+	tests := []struct {
+		userID string
+		quizID string
+		allow  bool
+	}{
+		{newcomer, "hiragana_katakana", true}, // level terendah tanpa syarat
+		{climber, "Level_2", true},
+		{climber, "Level_3", false},
+	}
+	for _, tt := range tests {
+		selectQuiz(f, cfg, tt.userID, tt.quizID)
+		session, ok := sessions.Get(tt.userID)
+		if ok != tt.allow {
+			t.Errorf("%s selecting %s: session=%v, want %v (reply %q)", tt.userID, tt.quizID, ok, tt.allow, f.lastResponse())
+		}
+		if ok {
+			sessions.Delete(session.UserID)
+		}
+	}
+	if got := f.lastResponse(); !strings.Contains(got, "**"+CurrentCatalog().Quizzes["Level_2"].Label+"**") {
+		t.Errorf("strict ladder response = %q, want it to name Level_2", got)
+	}
+}
+
+// passQuiz memilih quiz lalu menyelesaikan semua tahapnya sebagai userID
+func passQuiz(t *testing.T, f *fakeGuild, cfg *GuildConfig, userID, quizID string) {
+	t.Helper()
+	user := &discordgo.User{ID: userID, Username: userID}
+	kotoba := &discordgo.User{ID: kotobaBotID, Username: "Kotoba", Bot: true}
+
+	selectQuiz(f, cfg, userID, quizID)
+	session, ok := sessions.Get(userID)
+	if !ok {
+		t.Fatalf("no session for %s; reply %q", quizID, f.lastResponse())
+	}
+	for _, stage := range session.Quiz.Stages {
+		sendMessage(f, session.ThreadID, user, stage.Command.String())
+		sendMessage(f, session.ThreadID, kotoba, "", kotobaResultEmbed(stage.ExpectedDeck(), fmt.Sprint(stage.ScoreLimit()), userID))
+	}
+	eventually(t, func() bool { _, ok := sessions.Get(userID); return !ok })
+	// Tunggu channel ditutup supaya goroutine cleanup tidak membaca
+	// konfigurasi test berikutnya
+	eventually(t, func() bool {
+		ch := f.channel(session.ThreadID)
+		return ch == nil || ch.ThreadMetadata != nil && ch.ThreadMetadata.Archived
+	})
+}
+
+func TestRoleStrategies(t *testing.T) {
+	const badge1, badge2 = "900000000000000001", "900000000000000002"
+	tests := []struct {
+		name       string
+		strategy   RoleStrategy
+		startRoles []string // quiz ID atau role badge
+		quizID     string
+		wantRoles  []string
+	}{
+		{"replace drops the old level", RoleReplace, []string{"Level_1"}, "Level_2", []string{"Level_2"}},
+		{"replace drops every lower level", RoleReplace, []string{"Level_1", "Level_2"}, "Level_3", []string{"Level_3"}},
+		{"badges drop every lower level", RoleBadges, []string{"Level_2", "Level_1"}, "Level_3", []string{"Level_3"}},
+		{"cumulative keeps every level", RoleCumulative, []string{"Level_1"}, "Level_2", []string{"Level_1", "Level_2"}},
+		{"badges keep one level plus badges", RoleBadges, []string{"Level_1", badge1}, "Level_2", []string{"Level_2", badge1, badge2}},
+		{"badges are given without a rank change", RoleBadges, []string{"Level_3"}, "Level_1", []string{"Level_3", badge1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, cfg := setupFlowTest(t)
+			cfg.RoleStrategy = tt.strategy
+			if tt.strategy == RoleBadges {
+				cfg.BadgeRoles = map[string]string{"Level_1": badge1, "Level_2": badge2}
+			}
+			role := func(id string) string {
+				if strings.HasPrefix(id, "Level_") {
+					return quizRole(t, cfg, id)
+				}
+				return id
+			}
+
+			const userID = "100000000000000001"
+			var start []string
+			for _, id := range tt.startRoles {
+				start = append(start, role(id))
+			}
+			f.addMember(userID, start...)
+
+			passQuiz(t, f, cfg, userID, tt.quizID)
+
+			var want []string
+			for _, id := range tt.wantRoles {
+				want = append(want, role(id))
+			}
+			sort.Strings(want)
+			if got := f.memberRoles(userID); !reflect.DeepEqual(got, want) {
+				t.Errorf("roles = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestGetCurrentQuizRoleLevel(t *testing.T) {
+	_, cfg := setupFlowTest(t)
+	l1, l3, l5 := quizRole(t, cfg, "Level_1"), quizRole(t, cfg, "Level_3"), quizRole(t, cfg, "Level_5")
+
+	// Urutan role member tidak berpengaruh
+	for _, roles := range [][]string{{l5, l1, l3}, {l1, l3, l5}, {l3, "other", l5, l1}} {
+		held := GetCurrentQuizRoleLevel(cfg, &discordgo.Member{Roles: roles})
+		if held.Level != 5 || held.RoleID != l5 {
+			t.Errorf("roles %v: level %d role %s, want 5 %s", roles, held.Level, held.RoleID, l5)
+		}
+		if !reflect.DeepEqual(held.Lower(), []string{l1, l3}) {
+			t.Errorf("roles %v: lower = %v", roles, held.Lower())
+		}
+	}
+	if held := GetCurrentQuizRoleLevel(cfg, &discordgo.Member{Roles: []string{"other"}}); held.Level != -1 || held.Lower() != nil {
+		t.Errorf("no quiz roles: %+v", held)
+	}
+}
+
+func TestReconcileQuizRoles(t *testing.T) {
+	f, cfg := setupFlowTest(t)
+	l1, l2, l4 := quizRole(t, cfg, "Level_1"), quizRole(t, cfg, "Level_2"), quizRole(t, cfg, "Level_4")
+	f.addMember("100000000000000001", l1, l4, "other")
+	f.addMember("100000000000000002", l2)
+	f.addMember("100000000000000003", l2, l1)
+
+	got := reconcileQuizRoles(f, cfg, testGuildID, "100000000000000009", "")
+	if !strings.Contains(got, "3 member diperiksa, 2 dirapikan") {
+		t.Errorf("summary = %q", got)
+	}
+
+	want := map[string][]string{
+		"100000000000000001": {l4, "other"},
+		"100000000000000002": {l2},
+		"100000000000000003": {l2},
+	}
+	for userID, roles := range want {
+		sort.Strings(roles)
+		if got := f.memberRoles(userID); !reflect.DeepEqual(got, roles) {
+			t.Errorf("%s roles = %v, want %v", userID, got, roles)
+		}
+	}
+
+	attempts, _ := history.ForUser(testGuildID, "100000000000000001")
+	if len(attempts) != 1 || attempts[0].Outcome != AttemptReconciled || !reflect.DeepEqual(attempts[0].RolesRemoved, []string{l1}) {
+		t.Errorf("history = %+v", attempts)
+	}
+}
+
+func TestThreadMode(t *testing.T) {
+	f, cfg := setupFlowTest(t)
+	cfg.QuizMode = QuizModeThread
+	const userID = "100000000000000001"
+	f.addMember(userID)
+
+	selectQuiz(f, cfg, userID, "Level_1")
+	session, ok := sessions.Get(userID)
+	if !ok {
+		t.Fatalf("no session; reply %q", f.lastResponse())
+	}
+	thread := f.channel(session.ThreadID)
+	if thread == nil || thread.Type != discordgo.ChannelTypeGuildPrivateThread || thread.ParentID != cfg.SelectorChannelID {
+		t.Fatalf("quiz place = %+v, want a private thread under the selector", thread)
+	}
+	if got := thread.ThreadMetadata.AutoArchiveDuration; got != 1440 {
+		t.Errorf("auto archive = %d, want 1440", got)
+	}
+	if got := f.threadMembers(thread.ID); !slices.Contains(got, userID) || !slices.Contains(got, kotobaBotID) {
+		t.Errorf("thread members = %v", got)
+	}
+	if got := len(f.channelsUnder(cfg.QuizCategoryID)); got != 1 {
+		t.Errorf("channels under quiz category = %d, want only the selector", got)
+	}
+	if reason := checkClosableQuizChannel(f, cfg, thread.ID); reason != "" {
+		t.Errorf("thread not closable: %q", reason)
+	}
+
+	// Thread private lain di bawah selector tidak boleh ditutup
+	f.addChannel(&discordgo.Channel{ID: "member-thread", Name: "ngobrol", Type: discordgo.ChannelTypeGuildPrivateThread, ParentID: cfg.SelectorChannelID, ThreadMetadata: &discordgo.ThreadMetadata{}})
+	if reason := checkClosableQuizChannel(f, cfg, "member-thread"); reason == "" {
+		t.Error("unrelated private thread is closable")
+	}
+
+	// Lulus → thread diarsipkan dan dikunci, bukan dihapus
+	passQuiz(t, f, cfg, userID, "Level_1")
+	archived := func() bool {
+		ch := f.channel(thread.ID)
+		return ch != nil && ch.ThreadMetadata.Archived && ch.ThreadMetadata.Locked
+	}
+	if !eventually(t, archived) {
+		t.Error("thread was not archived and locked after passing")
+	}
+}
+
+func TestSweeperDropsArchivedThreadSessions(t *testing.T) {
+	f, cfg := setupFlowTest(t)
+	const userID, channelUserID = "100000000000000001", "100000000000000002"
+	f.addMember(userID)
+	f.addMember(channelUserID)
+
+	// Sesi di channel biasa tidak perlu dicek ke Discord
+	selectQuiz(f, cfg, channelUserID, "Level_1")
+	channelSession, _ := sessions.Get(channelUserID)
+
+	cfg.QuizMode = QuizModeThread
+	selectQuiz(f, cfg, userID, "Level_1")
+	session, _ := sessions.Get(userID)
+	if !session.InThread || channelSession.InThread {
+		t.Fatalf("InThread = %v (thread), %v (channel)", session.InThread, channelSession.InThread)
+	}
+
+	// Discord mengarsipkan thread yang tidak aktif
+	archived := true
+	f.ChannelEditComplex(session.ThreadID, &discordgo.ChannelEdit{Archived: &archived})
+	lookups := f.lookups[channelSession.ThreadID]
+	sweepInactiveQuizChannels(f)
+
+	if _, ok := sessions.Get(userID); ok {
+		t.Error("session survived an archived thread")
+	}
+	if _, ok := sessions.Get(channelUserID); !ok {
+		t.Error("channel session was dropped")
+	}
+	if n := f.lookups[channelSession.ThreadID] - lookups; n != 0 {
+		t.Errorf("sweeper looked up a channel-mode session %d times", n)
+	}
+	if ch := f.channel(session.ThreadID); ch == nil || !ch.ThreadMetadata.Locked {
+		t.Error("archived thread was not locked")
+	}
+	attempts, _ := history.ForUser(testGuildID, userID)
+	if len(attempts) != 1 || attempts[0].Outcome != AttemptAbandoned {
+		t.Errorf("history = %+v", attempts)
+	}
+}
+
+func TestSelectAfterArchivedThread(t *testing.T) {
+	f, cfg := setupFlowTest(t)
+	cfg.QuizMode = QuizModeThread
+	const userID = "100000000000000001"
+	f.addMember(userID)
+
+	selectQuiz(f, cfg, userID, "Level_1")
+	old, _ := sessions.Get(userID)
+	archived := true
+	f.ChannelEditComplex(old.ThreadID, &discordgo.ChannelEdit{Archived: &archived})
+
+	// Thread yang diarsipkan tidak lagi dihitung sebagai quiz aktif
+	selectQuiz(f, cfg, userID, "Level_2")
+	session, ok := sessions.Get(userID)
+	if !ok || session.QuizID != "Level_2" {
+		t.Fatalf("session = %+v, %v; reply %q", session, ok, f.lastResponse())
+	}
+	if ch := f.channel(old.ThreadID); ch == nil || !ch.ThreadMetadata.Locked {
+		t.Error("archived thread was not locked")
+	}
+	attempts, _ := history.ForUser(testGuildID, userID)
+	if len(attempts) != 1 || attempts[0].QuizID != "Level_1" || attempts[0].Outcome != AttemptAbandoned {
+		t.Errorf("history = %+v", attempts)
+	}
+}
+
+// fillCategory mengisi kategori sampai batas channel Discord
+func fillCategory(f *fakeGuild, categoryID string) {
+	for n := len(f.channelsUnder(categoryID)); n < maxCategoryChannels; n++ {
+		f.addChannel(&discordgo.Channel{ID: fmt.Sprintf("%s-filler-%d", categoryID, n), Type: discordgo.ChannelTypeGuildText, ParentID: categoryID})
+	}
+}
+
+func TestOverflowCategories(t *testing.T) {
+	const userID = "100000000000000001"
+
+	t.Run("configured overflow category", func(t *testing.T) {
+		f, cfg := setupFlowTest(t)
+		f.addMember(userID)
+		f.addChannel(&discordgo.Channel{ID: "category-2", Type: discordgo.ChannelTypeGuildCategory})
+		cfg.QuizCategoryIDs = []string{"category-2"}
+		fillCategory(f, cfg.QuizCategoryID)
+
+		selectQuiz(f, cfg, userID, "Level_1")
+		session, ok := sessions.Get(userID)
+		if !ok {
+			t.Fatalf("no session; reply %q", f.lastResponse())
+		}
+		if got := f.channel(session.ThreadID).ParentID; got != "category-2" {
+			t.Errorf("quiz channel parent = %s, want category-2", got)
+		}
+		if reason := checkClosableQuizChannel(f, cfg, session.ThreadID); reason != "" {
+			t.Errorf("overflow channel not closable: %q", reason)
+		}
+	})
+
+	t.Run("every category full", func(t *testing.T) {
+		f, cfg := setupFlowTest(t)
+		f.addMember(userID)
+		fillCategory(f, cfg.QuizCategoryID)
+
+		selectQuiz(f, cfg, userID, "Level_1")
+		if _, ok := sessions.Get(userID); ok {
+			t.Fatal("session created without a free category")
+		}
+		if got := f.lastResponse(); !strings.Contains(got, "kategori quiz sedang penuh") {
+			t.Errorf("response = %q", got)
+		}
+	})
+
+	t.Run("automatic overflow category", func(t *testing.T) {
+		f, cfg := setupFlowTest(t)
+		f.addMember(userID)
+		cfg.AutoOverflowCategories = true
+		fillCategory(f, cfg.QuizCategoryID)
+
+		selectQuiz(f, cfg, userID, "Level_1")
+		session, ok := sessions.Get(userID)
+		if !ok {
+			t.Fatalf("no session; reply %q", f.lastResponse())
+		}
+		parent := f.channel(f.channel(session.ThreadID).ParentID)
+		if parent.Type != discordgo.ChannelTypeGuildCategory || parent.Name != "Quiz 2" {
+			t.Fatalf("quiz channel parent = %+v, want new category Quiz 2", parent)
+		}
+		if reason := checkClosableQuizChannel(f, cfg, session.ThreadID); reason != "" {
+			t.Errorf("overflow channel not closable: %q", reason)
+		}
+
+		// Kategori overflow yang kosong dihapus sweeper
+		deleteQuizChannel(f, session.ThreadID)
+		sweepInactiveQuizChannels(f)
+		if f.hasChannel(parent.ID) {
+			t.Error("empty overflow category was not removed")
+		}
+		if !f.hasChannel(cfg.QuizCategoryID) {
+			t.Error("configured category was removed")
+		}
+		if ids := overflowCategories.IDs(testGuildID); len(ids) != 0 {
+			t.Errorf("removed overflow category still stored: %v", ids)
+		}
+	})
+
+	t.Run("categories with a similar name are not touched", func(t *testing.T) {
+		f, cfg := setupFlowTest(t)
+		f.addMember(userID)
+		cfg.AutoOverflowCategories = true
+		f.addChannel(&discordgo.Channel{ID: "quiz-info", Name: "Quiz Info", Type: discordgo.ChannelTypeGuildCategory})
+		f.addChannel(&discordgo.Channel{ID: "quiz-info-rules", Name: "rules", Type: discordgo.ChannelTypeGuildText, ParentID: "quiz-info"})
+		f.addChannel(&discordgo.Channel{ID: "quiz-archive", Name: "Quiz Archive", Type: discordgo.ChannelTypeGuildCategory})
+		fillCategory(f, cfg.QuizCategoryID)
+
+		// Kategori bernama "Quiz ..." milik server bukan slot quiz
+		selectQuiz(f, cfg, userID, "Level_1")
+		session, ok := sessions.Get(userID)
+		if !ok {
+			t.Fatalf("no session; reply %q", f.lastResponse())
+		}
+		parentID := f.channel(session.ThreadID).ParentID
+		if parentID == "quiz-info" || parentID == "quiz-archive" {
+			t.Fatalf("quiz channel placed in unrelated category %s", parentID)
+		}
+		if !slices.Contains(overflowCategories.IDs(testGuildID), parentID) {
+			t.Errorf("new overflow category %s not stored", parentID)
+		}
+		if reason := checkClosableQuizChannel(f, cfg, "quiz-info-rules"); reason == "" {
+			t.Error("channel in unrelated category is closable")
+		}
+
+		// Sweeper tidak menghapus kategori kosong yang bukan buatan bot
+		sweepInactiveQuizChannels(f)
+		if !f.hasChannel("quiz-archive") || !f.hasChannel("quiz-info") {
+			t.Error("unrelated category was removed")
+		}
+	})
+}
+
+func TestProvisionQueue(t *testing.T) {
+	const first, second, third, fourth = "100000000000000001", "100000000000000002", "100000000000000003", "100000000000000004"
+	f, cfg := setupFlowTest(t)
+	cfg.MaxOpenQuizzes = 1
+	cfg.MaxQueueLength = 2
+	for _, userID := range []string{first, second, third, fourth} {
+		f.addMember(userID)
+	}
+
+	selectQuiz(f, cfg, first, "Level_1")
+	active, ok := sessions.Get(first)
+	if !ok {
+		t.Fatalf("no session for first user; reply %q", f.lastResponse())
+	}
+
+	selectQuiz(f, cfg, second, "Level_1")
+	if got := f.lastResponse(); !strings.Contains(got, "antrian **#1**") {
+		t.Errorf("second user response = %q, want queue position #1", got)
+	}
+	selectQuiz(f, cfg, third, "Level_2")
+	if got := f.lastResponse(); !strings.Contains(got, "antrian **#2**") {
+		t.Errorf("third user response = %q, want queue position #2", got)
+	}
+	selectQuiz(f, cfg, second, "Level_1")
+	if got := f.lastResponse(); !strings.Contains(got, "masih di antrian **#1**") {
+		t.Errorf("repeated select response = %q", got)
+	}
+	selectQuiz(f, cfg, fourth, "Level_1")
+	if got := f.lastResponse(); !strings.Contains(got, "Antrian quiz sedang penuh") {
+		t.Errorf("full queue response = %q", got)
+	}
+	if !sessions.Reserve(fourth) {
+		t.Error("rejected user is still reserved")
+	}
+	sessions.Release(fourth)
+	if _, ok := sessions.Get(second); ok {
+		t.Fatal("queued user got a session while the limit was reached")
+	}
+
+	// Quiz pertama selesai, user berikutnya di antrian mendapat channel
+	for _, next := range []string{second, third} {
+		deleteQuizChannel(f, active.ThreadID)
+		if !eventually(t, func() bool { _, ok := sessions.Get(next); return ok }) {
+			t.Fatalf("user %s was not provisioned after a slot opened", next)
+		}
+		provisioner.Wait()
+		active, _ = sessions.Get(next)
+		if got := f.lastMessage("dm-" + next); !strings.Contains(got, "<#"+active.ThreadID+">") {
+			t.Errorf("DM to %s = %q, want link to quiz channel", next, got)
+		}
+		if got := f.lastMessage(active.ThreadID); !strings.Contains(got, "<@"+next+">") {
+			t.Errorf("welcome message does not mention %s: %q", next, got)
+		}
+		if n := sessions.CountGuild(testGuildID); n != 1 {
+			t.Errorf("open quizzes = %d, want 1", n)
+		}
+	}
+	deleteQuizChannel(f, active.ThreadID)
+}
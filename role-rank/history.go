@@ -0,0 +1,423 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// AttemptOutcome adalah akhir sebuah percobaan quiz.
+type AttemptOutcome string
+
+const (
+	AttemptPassed     AttemptOutcome = "passed"     // semua tahap selesai, role baru diberikan
+	AttemptNoChange   AttemptOutcome = "no_change"  // sudah punya role level yang sama
+	AttemptRejected   AttemptOutcome = "rejected"   // semua tahap selesai tapi role tidak diberikan
+	AttemptError      AttemptOutcome = "error"      // panggilan role ke Discord gagal
+	AttemptAbandoned  AttemptOutcome = "abandoned"  // channel ditutup sebelum selesai
+	AttemptCleared    AttemptOutcome = "cleared"    // bukan quiz: role dicabut moderator
+	AttemptReconciled AttemptOutcome = "reconciled" // bukan quiz: role level ganda dirapikan moderator
+)
+
+// Attempt adalah satu baris riwayat: satu percobaan quiz dari pilih level
+// sampai channel ditutup, atau satu tindakan moderator.
+type Attempt struct {
+	GuildID      string         `json:"guildId"`
+	UserID       string         `json:"userId"`
+	QuizID       string         `json:"quizId,omitempty"`
+	Level        int            `json:"level"`
+	StartedAt    time.Time      `json:"startedAt"`
+	EndedAt      time.Time      `json:"endedAt"`
+	Stages       []StageAttempt `json:"stages,omitempty"`
+	Outcome      AttemptOutcome `json:"outcome"`
+	Reason       string         `json:"reason,omitempty"`
+	RolesAdded   []string       `json:"rolesAdded,omitempty"`
+	RolesRemoved []string       `json:"rolesRemoved,omitempty"`
+	ModeratorID  string         `json:"moderatorId,omitempty"`
+}
+
+// StageAttempt adalah satu hasil Kotoba yang diproses untuk sebuah tahap.
+type StageAttempt struct {
+	Stage     int                     `json:"stage"`
+	StartedAt time.Time               `json:"startedAt,omitzero"`
+	EndedAt   time.Time               `json:"endedAt"`
+	Result    KotobaResult            `json:"result"`
+	Embed     *discordgo.MessageEmbed `json:"embed,omitempty"` // embed asli dari Kotoba
+	Passed    bool                    `json:"passed"`
+	Reason    string                  `json:"reason,omitempty"` // alasan hasil tidak dihitung
+}
+
+// HistoryStore menyimpan riwayat percobaan quiz. Riwayat hanya ditambah,
+// tidak pernah diubah.
+type HistoryStore interface {
+	Append(attempt Attempt) error
+	ForUser(guildID, userID string) ([]Attempt, error) // terbaru duluan
+	// LastAttempt mengembalikan ringkasan percobaan terakhir user di level
+	// quizID, tanpa membaca file riwayat
+	LastAttempt(guildID, userID, quizID string) (AttemptSummary, bool, error)
+}
+
+// AttemptSummary adalah bagian Attempt yang disimpan di memori untuk cek
+// cooldown.
+type AttemptSummary struct {
+	Outcome AttemptOutcome
+	EndedAt time.Time
+}
+
+// FileHistoryStore menyimpan riwayat sebagai JSON Lines, satu Attempt per
+// baris. Append hanya menambah baris sehingga riwayat lama tidak ikut
+// tertulis ulang. Posisi baris setiap user diindeks di memori saat file
+// pertama kali dibaca, jadi ForUser hanya membaca baris milik user itu.
+type FileHistoryStore struct {
+	path  string
+	mu    sync.Mutex
+	index map[historyKey][]historySpan // nil = file belum diindeks
+	size  int64                        // panjang file yang sudah diindeks
+	torn  bool                         // baris terakhir tidak diakhiri newline
+}
+
+type historyKey struct {
+	guildID, userID string
+}
+
+// historySpan adalah letak satu baris riwayat di file beserta ringkasannya.
+type historySpan struct {
+	offset  int64
+	length  int
+	quizID  string
+	summary AttemptSummary
+}
+
+func NewFileHistoryStore(path string) *FileHistoryStore {
+	return &FileHistoryStore{path: path}
+}
+
+func (f *FileHistoryStore) Append(attempt Attempt) error {
+	data, err := json.Marshal(attempt)
+	if err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.load(); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(f.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	line := append(data, '\n')
+	if f.torn {
+		// Baris terpotong ditutup dulu supaya baris baru tidak ikut rusak
+		line = append([]byte{'\n'}, line...)
+		f.size++
+		f.torn = false
+	}
+	if _, err := file.Write(line); err != nil {
+		file.Close()
+		// Panjang file tidak pasti lagi, indeks dibangun ulang nanti
+		f.index = nil
+		return err
+	}
+	key := historyKey{attempt.GuildID, attempt.UserID}
+	f.index[key] = append(f.index[key], historySpan{
+		offset:  f.size,
+		length:  len(data),
+		quizID:  attempt.QuizID,
+		summary: AttemptSummary{Outcome: attempt.Outcome, EndedAt: attempt.EndedAt},
+	})
+	f.size += int64(len(data)) + 1
+	return file.Close()
+}
+
+func (f *FileHistoryStore) ForUser(guildID, userID string) ([]Attempt, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.load(); err != nil {
+		return nil, err
+	}
+	spans := f.index[historyKey{guildID, userID}]
+	if len(spans) == 0 {
+		return nil, nil
+	}
+
+	file, err := os.Open(f.path)
+	if err != nil {
+		return nil, fmt.Errorf("gagal membaca %s: %w", f.path, err)
+	}
+	defer file.Close()
+
+	// File urut dari yang terlama
+	list := make([]Attempt, 0, len(spans))
+	for n := len(spans) - 1; n >= 0; n-- {
+		buf := make([]byte, spans[n].length)
+		if _, err := file.ReadAt(buf, spans[n].offset); err != nil {
+			return nil, fmt.Errorf("gagal membaca %s: %w", f.path, err)
+		}
+		var a Attempt
+		if err := json.Unmarshal(buf, &a); err != nil {
+			return nil, fmt.Errorf("riwayat %s rusak di byte %d: %w", f.path, spans[n].offset, err)
+		}
+		list = append(list, a)
+	}
+	return list, nil
+}
+
+func (f *FileHistoryStore) LastAttempt(guildID, userID, quizID string) (AttemptSummary, bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.load(); err != nil {
+		return AttemptSummary{}, false, err
+	}
+	spans := f.index[historyKey{guildID, userID}]
+	for n := len(spans) - 1; n >= 0; n-- {
+		if spans[n].quizID == quizID {
+			return spans[n].summary, true, nil
+		}
+	}
+	return AttemptSummary{}, false, nil
+}
+
+// load membaca seluruh file sekali untuk membangun indeks. Dipanggil dengan
+// mu terkunci.
+func (f *FileHistoryStore) load() error {
+	if f.index != nil {
+		return nil
+	}
+
+	index := make(map[historyKey][]historySpan)
+	file, err := os.Open(f.path)
+	if errors.Is(err, os.ErrNotExist) {
+		f.index, f.size, f.torn = index, 0, false
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("gagal membaca %s: %w", f.path, err)
+	}
+	defer file.Close()
+
+	var offset int64
+	torn := false
+	reader := bufio.NewReader(file)
+	for line := 1; ; line++ {
+		data, err := reader.ReadBytes('\n')
+		if len(data) > 0 {
+			record := bytes.TrimSuffix(data, []byte("\n"))
+			// Embed Kotoba dan tahap tidak perlu dibaca untuk indeks
+			var a struct {
+				GuildID string         `json:"guildId"`
+				UserID  string         `json:"userId"`
+				QuizID  string         `json:"quizId"`
+				EndedAt time.Time      `json:"endedAt"`
+				Outcome AttemptOutcome `json:"outcome"`
+			}
+			if err := json.Unmarshal(record, &a); err != nil {
+				// Baris terakhir bisa terpotong kalau proses mati saat menulis
+				log.Printf("Riwayat %s baris %d rusak, dilewati: %v", f.path, line, err)
+			} else {
+				k := historyKey{a.GuildID, a.UserID}
+				index[k] = append(index[k], historySpan{
+					offset:  offset,
+					length:  len(record),
+					quizID:  a.QuizID,
+					summary: AttemptSummary{Outcome: a.Outcome, EndedAt: a.EndedAt},
+				})
+			}
+			offset += int64(len(data))
+			torn = data[len(data)-1] != '\n'
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("gagal membaca %s: %w", f.path, err)
+		}
+	}
+	f.index, f.size, f.torn = index, offset, torn
+	return nil
+}
+
+// recordAttempt menutup percobaan quiz milik sesi dan menyimpannya ke
+// riwayat.
+func recordAttempt(session QuizSession, outcome AttemptOutcome, reason string, added, removed []string) {
+	appendHistory(Attempt{
+		GuildID:      session.GuildID,
+		UserID:       session.UserID,
+		QuizID:       session.QuizID,
+		Level:        session.Quiz.Level,
+		StartedAt:    session.CreatedAt,
+		EndedAt:      time.Now(),
+		Stages:       session.StageLog,
+		Outcome:      outcome,
+		Reason:       reason,
+		RolesAdded:   added,
+		RolesRemoved: removed,
+	})
+}
+
+func appendHistory(attempt Attempt) {
+	if history == nil {
+		return
+	}
+	if err := history.Append(attempt); err != nil {
+		log.Printf("Gagal menyimpan riwayat quiz user %s: %v", attempt.UserID, err)
+	}
+}
+
+// recordStageResult mencatat hasil Kotoba untuk tahap sesi saat ini.
+// reason kosong berarti hasil diterima.
+func recordStageResult(channelID string, embed *discordgo.MessageEmbed, result KotobaResult, reason string) {
+	session, ok := sessions.GetByChannel(channelID)
+	if !ok {
+		return
+	}
+	sessions.Update(session.UserID, func(sess *QuizSession) bool {
+		if sess.ThreadID != channelID {
+			return false
+		}
+		sess.StageLog = append(sess.StageLog, StageAttempt{
+			Stage:     sess.Progress,
+			StartedAt: sess.StageStartedAt,
+			EndedAt:   time.Now(),
+			Result:    result,
+			Embed:     embed,
+			Passed:    reason == "",
+			Reason:    reason,
+		})
+		return true
+	})
+}
+
+// Jumlah percobaan per halaman embed /quiz history
+const historyPageSize = 5
+
+var attemptOutcomeLabels = map[AttemptOutcome]string{
+	AttemptPassed:     "✅ lulus",
+	AttemptNoChange:   "➖ tidak ada perubahan",
+	AttemptRejected:   "⛔ ditolak",
+	AttemptError:      "⚠️ error",
+	AttemptAbandoned:  "🚪 ditinggalkan",
+	AttemptCleared:    "🧹 role dicabut moderator",
+	AttemptReconciled: "🔧 role level ganda dirapikan",
+}
+
+// historyMessage menyusun embed satu halaman riwayat user beserta tombol
+// halaman sebelumnya/berikutnya.
+func historyMessage(guildID, userID string, page int) (*discordgo.MessageEmbed, []discordgo.MessageComponent, error) {
+	attempts, err := history.ForUser(guildID, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pages := max(1, (len(attempts)+historyPageSize-1)/historyPageSize)
+	page = min(max(page, 0), pages-1)
+
+	embed := &discordgo.MessageEmbed{
+		Title:       "Riwayat quiz",
+		Description: fmt.Sprintf("<@%s> · %d catatan", userID, len(attempts)),
+		Footer:      &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("Halaman %d/%d", page+1, pages)},
+	}
+	if len(attempts) == 0 {
+		embed.Description = fmt.Sprintf("<@%s> belum punya riwayat quiz.", userID)
+		return embed, nil, nil
+	}
+
+	from := page * historyPageSize
+	for _, a := range attempts[from:min(from+historyPageSize, len(attempts))] {
+		embed.Fields = append(embed.Fields, attemptField(a))
+	}
+
+	if pages == 1 {
+		return embed, nil, nil
+	}
+	buttons := []discordgo.MessageComponent{discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+		discordgo.Button{
+			Label:    "◀",
+			Style:    discordgo.SecondaryButton,
+			CustomID: fmt.Sprintf("%s%s:%d", historyButtonPrefix, userID, page-1),
+			Disabled: page == 0,
+		},
+		discordgo.Button{
+			Label:    "▶",
+			Style:    discordgo.SecondaryButton,
+			CustomID: fmt.Sprintf("%s%s:%d", historyButtonPrefix, userID, page+1),
+			Disabled: page == pages-1,
+		},
+	}}}
+	return embed, buttons, nil
+}
+
+// Custom ID tombol halaman riwayat: quiz_history:<userID>:<halaman>
+const historyButtonPrefix = "quiz_history:"
+
+func attemptField(a Attempt) *discordgo.MessageEmbedField {
+	outcome, ok := attemptOutcomeLabels[a.Outcome]
+	if !ok {
+		outcome = string(a.Outcome)
+	}
+
+	moderatorAction := a.Outcome == AttemptCleared || a.Outcome == AttemptReconciled
+	name := "Tindakan moderator"
+	if !moderatorAction {
+		label := a.QuizID
+		if quiz, ok := CurrentCatalog().Quizzes[a.QuizID]; ok {
+			label = quiz.Label
+		}
+		name = fmt.Sprintf("%s (level %d)", label, a.Level)
+	}
+
+	lines := []string{outcome}
+	if moderatorAction {
+		lines[0] += fmt.Sprintf(" · <t:%d:f>", a.EndedAt.Unix())
+	} else {
+		lines[0] += fmt.Sprintf(" · <t:%d:f> – <t:%d:t>", a.StartedAt.Unix(), a.EndedAt.Unix())
+	}
+	for _, st := range a.Stages {
+		mark := "✅"
+		if !st.Passed {
+			mark = "❌"
+		}
+		line := fmt.Sprintf("%s Tahap %d: %s, score limit %d", mark, st.Stage+1, st.Result.Deck, st.Result.ScoreLimit)
+		if !st.StartedAt.IsZero() {
+			line += fmt.Sprintf(", %s", st.EndedAt.Sub(st.StartedAt).Round(time.Second))
+		}
+		if st.Reason != "" {
+			line += " — " + firstLine(st.Reason)
+		}
+		lines = append(lines, line)
+	}
+	if a.Reason != "" {
+		lines = append(lines, "Alasan: "+a.Reason)
+	}
+	for _, id := range a.RolesAdded {
+		lines = append(lines, "+ <@&"+id+">")
+	}
+	for _, id := range a.RolesRemoved {
+		lines = append(lines, "− <@&"+id+">")
+	}
+	if a.ModeratorID != "" {
+		lines = append(lines, "Moderator: <@"+a.ModeratorID+">")
+	}
+
+	// Batas Discord untuk isi field embed
+	return &discordgo.MessageEmbedField{Name: name, Value: truncateRunes(strings.Join(lines, "\n"), 1024)}
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	return line
+}
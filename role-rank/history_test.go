@@ -0,0 +1,100 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileHistoryStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	store := NewFileHistoryStore(path)
+
+	base := time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC)
+	for n, a := range []Attempt{
+		{GuildID: "g1", UserID: "u1", QuizID: "Level_1", Outcome: AttemptAbandoned},
+		{GuildID: "g1", UserID: "u2", QuizID: "Level_1", Outcome: AttemptPassed},
+		{GuildID: "g2", UserID: "u1", QuizID: "Level_1", Outcome: AttemptPassed},
+		{GuildID: "g1", UserID: "u1", QuizID: "Level_2", Outcome: AttemptPassed},
+	} {
+		a.EndedAt = base.Add(time.Duration(n) * time.Minute)
+		if err := store.Append(a); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Baris terpotong di akhir file (proses mati saat menulis) dilewati
+	// setelah restart
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.WriteString(`{"guildId":"g1","userId":"u1","quizId":`)
+	file.Close()
+	store = NewFileHistoryStore(path)
+
+	got, err := store.ForUser("g1", "u1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].QuizID != "Level_2" || got[1].QuizID != "Level_1" {
+		t.Errorf("ForUser = %+v, want Level_2 then Level_1", got)
+	}
+
+	// Baris baru setelah indeks dibuat ikut terbaca, juga oleh store baru
+	// yang membangun indeks dari file
+	if err := store.Append(Attempt{GuildID: "g1", UserID: "u1", QuizID: "Level_3", Outcome: AttemptPassed}); err != nil {
+		t.Fatal(err)
+	}
+	for name, s := range map[string]*FileHistoryStore{"same store": store, "reopened": NewFileHistoryStore(path)} {
+		got, err := s.ForUser("g1", "u1")
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if len(got) != 3 || got[0].QuizID != "Level_3" || got[2].QuizID != "Level_1" {
+			t.Errorf("%s: ForUser = %+v, want Level_3, Level_2, Level_1", name, got)
+		}
+	}
+
+	// Ringkasan percobaan terakhir per level untuk cek cooldown
+	for name, s := range map[string]*FileHistoryStore{"same store": store, "reopened": NewFileHistoryStore(path)} {
+		last, ok, err := s.LastAttempt("g1", "u1", "Level_1")
+		if err != nil || !ok || last.Outcome != AttemptAbandoned || !last.EndedAt.Equal(base) {
+			t.Errorf("%s: LastAttempt(Level_1) = %+v, %v, %v", name, last, ok, err)
+		}
+		if _, ok, _ := s.LastAttempt("g1", "u1", "Level_5"); ok {
+			t.Errorf("%s: LastAttempt found an attempt for an untried level", name)
+		}
+	}
+
+	if got, err := NewFileHistoryStore(filepath.Join(t.TempDir(), "missing.jsonl")).ForUser("g1", "u1"); err != nil || got != nil {
+		t.Errorf("missing file: %v, %v", got, err)
+	}
+}
+
+func TestHistoryPages(t *testing.T) {
+	old := history
+	history = NewFileHistoryStore(filepath.Join(t.TempDir(), "history.jsonl"))
+	t.Cleanup(func() { history = old })
+
+	for n := 0; n < historyPageSize+2; n++ {
+		history.Append(Attempt{GuildID: "g1", UserID: "u1", QuizID: "Level_1", Outcome: AttemptAbandoned})
+	}
+
+	embed, components, err := historyMessage("g1", "u1", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(embed.Fields) != 2 || embed.Footer.Text != "Halaman 2/2" {
+		t.Errorf("page 2 has %d fields, footer %q", len(embed.Fields), embed.Footer.Text)
+	}
+	if len(components) != 1 {
+		t.Fatalf("components = %d, want one row of buttons", len(components))
+	}
+
+	// Halaman di luar jangkauan dipotong ke halaman terakhir
+	if embed, _, _ := historyMessage("g1", "u1", 9); embed.Footer.Text != "Halaman 2/2" {
+		t.Errorf("footer = %q", embed.Footer.Text)
+	}
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// KotobaOutcome adalah cara sebuah quiz Kotoba berakhir.
+type KotobaOutcome string
+
+const (
+	KotobaCompleted KotobaOutcome = "completed" // score limit tercapai
+	KotobaFailed    KotobaOutcome = "failed"    // terlalu banyak soal tidak terjawab / soal habis
+	KotobaStopped   KotobaOutcome = "stopped"   // dihentikan dengan k!quiz stop
+	KotobaUnknown   KotobaOutcome = "unknown"   // embed akhir dengan kalimat yang belum dikenal
+)
+
+// KotobaParticipant adalah satu baris papan skor akhir Kotoba.
+type KotobaParticipant struct {
+	UserID string `json:"userId,omitempty"`
+	Name   string `json:"name,omitempty"`
+	Score  int    `json:"score"`
+}
+
+// KotobaResult adalah isi embed akhir quiz Kotoba dalam bentuk terstruktur.
+type KotobaResult struct {
+	Outcome      KotobaOutcome       `json:"outcome"`
+	Deck         string              `json:"deck"`
+	ScoreLimit   int                 `json:"scoreLimit,omitempty"`
+	WinnerID     string              `json:"winnerId,omitempty"`
+	WinnerName   string              `json:"winnerName,omitempty"`
+	Participants []KotobaParticipant `json:"participants,omitempty"`
+	Questions    int                 `json:"questions,omitempty"` // soal terjawab + tidak terjawab
+	Reason       string              `json:"reason,omitempty"`    // kalimat penutup dari Kotoba
+
+	// Daftar soal tidak terjawab terpotong, jadi Questions hanya batas bawah
+	QuestionsAtLeast bool `json:"questionsAtLeast,omitempty"`
+}
+
+// Batas panjang value field embed Discord. Daftar soal tidak terjawab yang
+// lebih panjang dipotong Kotoba.
+const embedFieldValueLimit = 1024
+
+var (
+	kotobaScoreLimitRe = regexp.MustCompile(`(?i)score limit of (\d+)`)
+	// Nama boleh berisi titik ("@john.doe."), jadi berhenti di
+	// ". Congratulations" atau akhir baris
+	kotobaReachedByRe  = regexp.MustCompile(`(?im)reached by (?:<@!?(\d+)>|@?(.+?))(?:[.!]\s*congratulations|[.!]?\s*$)`)
+	kotobaScoreLineRe  = regexp.MustCompile(`(?i)^(?:<@!?(\d+)>|@?(.+?))\s+has\s+(\d+)\s+points?`)
+	kotobaListMarkerRe = regexp.MustCompile(`^(?:\d+[.)]|[🥇🥈🥉#*-])\s*`)
+	kotobaNumberRe     = regexp.MustCompile(`\d+`)
+)
+
+// ParseKotobaResult membaca embed akhir quiz Kotoba. Hasil false berarti
+// embed ini bukan embed akhir quiz (misalnya embed soal atau embed mulai).
+func ParseKotobaResult(embed *discordgo.MessageEmbed) (KotobaResult, bool) {
+	if embed == nil || !strings.HasSuffix(embed.Title, " Ended") {
+		return KotobaResult{}, false
+	}
+
+	result := KotobaResult{
+		Outcome: KotobaUnknown,
+		Deck:    strings.TrimSuffix(embed.Title, " Ended"),
+		Reason:  strings.TrimSpace(embed.Description),
+	}
+	desc := strings.ToLower(embed.Description)
+	unanswered, total := 0, 0
+
+	switch {
+	case strings.Contains(desc, "congratulations!"):
+		result.Outcome = KotobaCompleted
+	case strings.Contains(desc, "unanswered"), strings.Contains(desc, "no questions left"), strings.Contains(desc, "out of questions"):
+		result.Outcome = KotobaFailed
+	case strings.Contains(desc, "stop"):
+		result.Outcome = KotobaStopped
+	}
+
+	if m := kotobaScoreLimitRe.FindStringSubmatch(embed.Description); m != nil {
+		result.ScoreLimit, _ = strconv.Atoi(m[1])
+	}
+	if m := kotobaReachedByRe.FindStringSubmatch(embed.Description); m != nil {
+		result.WinnerID = m[1]
+		result.WinnerName = strings.TrimSpace(m[2])
+	}
+
+	for _, f := range embed.Fields {
+		name := strings.ToLower(f.Name)
+		switch {
+		case strings.Contains(name, "score limit"):
+			// Beberapa versi Kotoba menaruh score limit di field terpisah
+			if n := kotobaNumberRe.FindString(f.Value); n != "" {
+				result.ScoreLimit, _ = strconv.Atoi(n)
+			}
+		case strings.Contains(name, "scores"), strings.Contains(name, "scoreboard"):
+			result.Participants = parseKotobaScores(f.Value)
+		case strings.Contains(name, "unanswered"):
+			unanswered, result.QuestionsAtLeast = countUnanswered(f.Value)
+		case strings.Contains(name, "question"):
+			// Jumlah soal dari Kotoba sendiri, kalau ada
+			if n := kotobaNumberRe.FindString(f.Value); n != "" {
+				total, _ = strconv.Atoi(n)
+			}
+		}
+	}
+
+	if total > 0 {
+		result.Questions = total
+		result.QuestionsAtLeast = false
+		return result, true
+	}
+
+	// Tanpa jumlah dari Kotoba, setiap poin adalah satu soal yang terjawab,
+	// ditambah soal yang tidak terjawab sama sekali.
+	for _, p := range result.Participants {
+		result.Questions += p.Score
+	}
+	result.Questions += unanswered
+
+	return result, true
+}
+
+// countUnanswered menghitung baris daftar soal tidak terjawab. Daftar yang
+// berakhir dengan elipsis atau sepanjang batas field sudah dipotong: baris
+// terakhirnya tidak dihitung dan hasilnya hanya batas bawah.
+func countUnanswered(value string) (count int, truncated bool) {
+	value = strings.TrimSpace(value)
+	truncated = utf8.RuneCountInString(value) >= embedFieldValueLimit ||
+		strings.HasSuffix(value, "…") || strings.HasSuffix(value, "...")
+
+	lines := strings.Split(value, "\n")
+	if truncated {
+		lines = lines[:len(lines)-1]
+	}
+	for _, line := range lines {
+		if strings.TrimSpace(line) != "" {
+			count++
+		}
+	}
+	return count, truncated
+}
+
+// QuestionCount menulis jumlah soal untuk pesan ke user, dengan "minimal"
+// kalau jumlahnya hanya batas bawah.
+func (r KotobaResult) QuestionCount() string {
+	if r.QuestionsAtLeast {
+		return fmt.Sprintf("minimal %d", r.Questions)
+	}
+	return strconv.Itoa(r.Questions)
+}
+
+// parseKotobaScores membaca baris "<@id> has N points" dari papan skor
+func parseKotobaScores(value string) []KotobaParticipant {
+	var list []KotobaParticipant
+	for _, line := range strings.Split(value, "\n") {
+		// Buang penanda urutan/medali di depan baris, mis. "1. " atau "🥇 ",
+		// tanpa memotong nama yang diawali angka seperti "2pac"
+		line = kotobaListMarkerRe.ReplaceAllString(strings.TrimSpace(line), "")
+		m := kotobaScoreLineRe.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		score, _ := strconv.Atoi(m[3])
+		list = append(list, KotobaParticipant{
+			UserID: m[1],
+			Name:   strings.TrimSpace(m[2]),
+			Score:  score,
+		})
+	}
+	return list
+}
+
+// Winner mengembalikan peserta yang mencapai score limit. Kalau kalimat
+// "reached by" tidak ada, dipakai skor tertinggi yang mencapai limit.
+func (r KotobaResult) Winner() (KotobaParticipant, bool) {
+	if r.WinnerID != "" || r.WinnerName != "" {
+		winner := KotobaParticipant{UserID: r.WinnerID, Name: r.WinnerName}
+		for _, p := range r.Participants {
+			if (p.UserID != "" && p.UserID == r.WinnerID) || (p.Name != "" && p.Name == r.WinnerName) {
+				winner.Score = p.Score
+			}
+		}
+		return winner, true
+	}
+
+	var best KotobaParticipant
+	found := false
+	for _, p := range r.Participants {
+		if r.ScoreLimit > 0 && p.Score >= r.ScoreLimit && (!found || p.Score > best.Score) {
+			best, found = p, true
+		}
+	}
+	return best, found
+}
+
+// ScoreOf mengembalikan skor user di papan skor akhir. Papan skor versi
+// lama hanya berisi nama; kalau hanya ada satu peserta, skornya dipakai.
+func (r KotobaResult) ScoreOf(userID string) (int, bool) {
+	for _, p := range r.Participants {
+		if p.UserID == userID {
+			return p.Score, true
+		}
+	}
+	if len(r.Participants) == 1 && r.Participants[0].UserID == "" {
+		return r.Participants[0].Score, true
+	}
+	return 0, false
+}
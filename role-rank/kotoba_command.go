@@ -0,0 +1,392 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// KotobaCommand adalah bentuk terstruktur command "k!quiz ...". Di katalog
+// quiz ditulis sebagai objek JSON; string command lama juga diterima.
+type KotobaCommand struct {
+	Decks      []string          `json:"decks"`                // deck, dipisah "+" di command
+	ScoreLimit int               `json:"scoreLimit,omitempty"` // angka tanpa key, 0 = default Kotoba
+	Modes      []string          `json:"modes,omitempty"`      // flag tanpa nilai, mis. hardcore, nd
+	Settings   map[string]string `json:"settings,omitempty"`   // key=value, mis. mmq=10
+}
+
+// Score limit Kotoba kalau command tidak menyebutkan angka
+const kotobaDefaultScoreLimit = 10
+
+// Judul embed hasil Kotoba untuk deck yang namanya berbeda dengan ID deck
+var kotobaDeckTitles = map[string]string{
+	"gn1": "JLPT N1 Grammar Quiz",
+	"gn2": "JLPT N2 Grammar Quiz",
+	"gn3": "JLPT N3 Grammar Quiz",
+	"gn4": "JLPT N4 Grammar Quiz",
+	"gn5": "JLPT N5 Grammar Quiz",
+}
+
+// Urutan setting saat command ditulis ulang; setting lain menyusul urut abjad
+var kotobaSettingOrder = []string{"mmq", "dauq", "daaq", "aaww", "font", "atl", "color", "bgcolor", "size", "effect"}
+
+// ParseKotobaCommand memecah command k!quiz menjadi deck, score limit,
+// mode dan setting.
+func ParseKotobaCommand(cmd string) (KotobaCommand, error) {
+	fields := strings.Fields(cmd)
+	if len(fields) < 2 || !strings.EqualFold(fields[0], "k!quiz") {
+		return KotobaCommand{}, errors.New("command harus diawali \"k!quiz <deck>\"")
+	}
+
+	c := KotobaCommand{
+		Decks:    strings.Split(strings.ToLower(fields[1]), "+"),
+		Settings: make(map[string]string),
+	}
+	for _, tok := range fields[2:] {
+		tok = strings.ToLower(tok)
+		if key, value, ok := strings.Cut(tok, "="); ok {
+			if _, dup := c.Settings[key]; dup {
+				return KotobaCommand{}, fmt.Errorf("setting %s ditulis lebih dari sekali", key)
+			}
+			c.Settings[key] = value
+			continue
+		}
+		if n, err := strconv.Atoi(tok); err == nil {
+			if c.ScoreLimit != 0 {
+				return KotobaCommand{}, fmt.Errorf("score limit ditulis lebih dari sekali (%d dan %d)", c.ScoreLimit, n)
+			}
+			c.ScoreLimit = n
+			continue
+		}
+		c.Modes = append(c.Modes, tok)
+	}
+	return c, nil
+}
+
+// String menulis ulang command dalam bentuk yang bisa di-paste ke Kotoba.
+func (c KotobaCommand) String() string {
+	parts := []string{"k!quiz", strings.Join(c.Decks, "+")}
+	if c.ScoreLimit > 0 {
+		parts = append(parts, strconv.Itoa(c.ScoreLimit))
+	}
+	parts = append(parts, c.Modes...)
+
+	written := make(map[string]bool)
+	for _, key := range kotobaSettingOrder {
+		if value, ok := c.Settings[key]; ok {
+			parts = append(parts, key+"="+value)
+			written[key] = true
+		}
+	}
+	var rest []string
+	for key := range c.Settings {
+		if !written[key] {
+			rest = append(rest, key)
+		}
+	}
+	sort.Strings(rest)
+	for _, key := range rest {
+		parts = append(parts, key+"="+c.Settings[key])
+	}
+	return strings.Join(parts, " ")
+}
+
+// EffectiveScoreLimit adalah score limit yang dipakai Kotoba.
+func (c KotobaCommand) EffectiveScoreLimit() int {
+	if c.ScoreLimit > 0 {
+		return c.ScoreLimit
+	}
+	return kotobaDefaultScoreLimit
+}
+
+// ResultTitle adalah nama deck di judul embed hasil Kotoba ("<deck> Ended").
+func (c KotobaCommand) ResultTitle() string {
+	if len(c.Decks) > 1 {
+		return "Multiple Deck Quiz"
+	}
+	if title, ok := kotobaDeckTitles[c.Decks[0]]; ok {
+		return title
+	}
+	return c.Decks[0]
+}
+
+// Validate memastikan command lengkap untuk dipakai di katalog.
+func (c KotobaCommand) Validate() error {
+	if len(c.Decks) == 0 {
+		return errors.New("minimal satu deck")
+	}
+	for _, d := range c.Decks {
+		if d == "" || strings.ContainsAny(d, " =") {
+			return fmt.Errorf("nama deck %q tidak valid", d)
+		}
+	}
+	if c.ScoreLimit < 0 {
+		return fmt.Errorf("score limit %d tidak boleh negatif", c.ScoreLimit)
+	}
+	for _, m := range c.Modes {
+		if m == "" || strings.ContainsAny(m, " =") {
+			return fmt.Errorf("mode %q tidak valid", m)
+		}
+		if _, err := strconv.Atoi(m); err == nil {
+			return fmt.Errorf("mode %q berupa angka, pakai scoreLimit", m)
+		}
+	}
+	for key, value := range c.Settings {
+		if key == "" || value == "" || strings.ContainsAny(key+value, " =") {
+			return fmt.Errorf("setting %s=%s tidak valid", key, value)
+		}
+	}
+	return nil
+}
+
+func (c *KotobaCommand) UnmarshalJSON(data []byte) error {
+	// Bentuk lama: string command utuh
+	var raw string
+	if err := json.Unmarshal(data, &raw); err == nil {
+		parsed, err := ParseKotobaCommand(raw)
+		if err != nil {
+			return err
+		}
+		*c = parsed
+		return nil
+	}
+
+	type plain KotobaCommand
+	var p plain
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&p); err != nil {
+		return err
+	}
+
+	// Samakan dengan ParseKotobaCommand supaya Diff dengan command yang
+	// ditulis user tidak beda hanya karena huruf besar
+	for n := range p.Decks {
+		p.Decks[n] = strings.ToLower(p.Decks[n])
+	}
+	for n := range p.Modes {
+		p.Modes[n] = strings.ToLower(p.Modes[n])
+	}
+	if p.Settings != nil {
+		settings := make(map[string]string, len(p.Settings))
+		for key, value := range p.Settings {
+			key = strings.ToLower(key)
+			if _, dup := settings[key]; dup {
+				return fmt.Errorf("setting %s ditulis lebih dari sekali", key)
+			}
+			settings[key] = strings.ToLower(value)
+		}
+		p.Settings = settings
+	}
+	*c = KotobaCommand(p)
+	return nil
+}
+
+// HasMode cek apakah flag mode (mis. hardcore) ada di command.
+func (c KotobaCommand) HasMode(mode string) bool {
+	for _, m := range c.Modes {
+		if m == mode {
+			return true
+		}
+	}
+	return false
+}
+
+// Nama field di embed mulai/akhir Kotoba -> key setting di command
+var kotobaSettingFields = map[string]string{
+	"score limit":                     "score",
+	"answer time limit":               "atl",
+	"max missed questions":            "mmq",
+	"delay after unanswered question": "dauq",
+	"delay after answered question":   "daaq",
+	"additional answer wait window":   "aaww",
+	"font":                            "font",
+	"font size":                       "size",
+	"font color":                      "color",
+	"background color":                "bgcolor",
+	"effect":                          "effect",
+	"hardcore mode":                   "hardcore",
+}
+
+var kotobaDecimalRe = regexp.MustCompile(`\d+(?:\.\d+)?`)
+
+// ParseKotobaSettings membaca setting quiz yang dilaporkan Kotoba di field
+// embed. Key mengikuti key di command (atl, mmq, ...), "score" untuk
+// score limit dan "hardcore" berisi "true"/"false".
+func ParseKotobaSettings(embed *discordgo.MessageEmbed) map[string]string {
+	settings := make(map[string]string)
+	if embed == nil {
+		return settings
+	}
+	for _, f := range embed.Fields {
+		key, ok := kotobaSettingFields[strings.ToLower(strings.TrimSpace(f.Name))]
+		if !ok {
+			continue
+		}
+		value := strings.ToLower(strings.TrimSpace(f.Value))
+		if key == "hardcore" {
+			switch value {
+			case "yes", "on", "enabled", "true":
+				value = "true"
+			default:
+				value = "false"
+			}
+		}
+		settings[key] = value
+	}
+	return settings
+}
+
+// IsKotobaStartEmbed cek apakah embed adalah pengumuman mulai quiz, yaitu
+// embed yang bukan embed akhir tapi berisi beberapa setting quiz.
+func IsKotobaStartEmbed(embed *discordgo.MessageEmbed) bool {
+	if embed == nil || strings.HasSuffix(embed.Title, " Ended") {
+		return false
+	}
+	return len(ParseKotobaSettings(embed)) >= 2
+}
+
+// CompareReported membandingkan command yang diharapkan dengan setting
+// yang dilaporkan Kotoba. Setting yang tidak dilaporkan tidak bisa dicek
+// dan dilewati. Hasilnya daftar perbedaan yang bisa dibaca user.
+func (c KotobaCommand) CompareReported(reported map[string]string) []string {
+	var diffs []string
+
+	if got, ok := reported["score"]; ok {
+		if !sameSettingValue(strconv.Itoa(c.EffectiveScoreLimit()), got) {
+			diffs = append(diffs, fmt.Sprintf("score limit: seharusnya %d, tercatat %s", c.EffectiveScoreLimit(), got))
+		}
+	}
+	if got, ok := reported["hardcore"]; ok && c.HasMode("hardcore") && got != "true" {
+		diffs = append(diffs, "hardcore: seharusnya aktif, tercatat tidak aktif")
+	}
+
+	keys := make([]string, 0, len(c.Settings))
+	for key := range c.Settings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		got, ok := reported[key]
+		if !ok {
+			continue
+		}
+		if want := c.Settings[key]; !sameSettingValue(want, got) {
+			diffs = append(diffs, fmt.Sprintf("%s: seharusnya %s, tercatat %s", key, want, got))
+		}
+	}
+	return diffs
+}
+
+// sameSettingValue membandingkan nilai setting. Kalau yang diharapkan
+// berupa angka, angka pertama di laporan Kotoba yang dibandingkan
+// ("16 seconds" cocok dengan "16"); laporan tanpa angka dianggap tidak
+// bisa dicek (mis. font dilaporkan sebagai nama font). Nilai lain
+// dibandingkan tanpa huruf besar, spasi dan tanda baca ("Anti-OCR" cocok
+// dengan "antiocr").
+func sameSettingValue(want, got string) bool {
+	wantNum, err := strconv.ParseFloat(want, 64)
+	if err != nil {
+		return normalizeSettingValue(want) == normalizeSettingValue(got)
+	}
+	n := kotobaDecimalRe.FindString(got)
+	if n == "" {
+		return true
+	}
+	gotNum, err := strconv.ParseFloat(n, 64)
+	return err == nil && gotNum == wantNum
+}
+
+func normalizeSettingValue(value string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, value)
+}
+
+// Subcommand k!quiz yang bukan nama deck
+var kotobaSubcommands = map[string]bool{
+	"stop":   true,
+	"help":   true,
+	"search": true,
+	"config": true,
+}
+
+// Diff membandingkan command yang diharapkan (c) dengan command lain.
+// Urutan deck, mode dan setting tidak dipermasalahkan.
+func (c KotobaCommand) Diff(got KotobaCommand) []string {
+	var diffs []string
+
+	if !sameSet(c.Decks, got.Decks) {
+		diffs = append(diffs, fmt.Sprintf("deck: seharusnya %s, tertulis %s",
+			strings.Join(c.Decks, "+"), strings.Join(got.Decks, "+")))
+	}
+	if c.EffectiveScoreLimit() != got.EffectiveScoreLimit() {
+		diffs = append(diffs, fmt.Sprintf("score limit: seharusnya %d, tertulis %d",
+			c.EffectiveScoreLimit(), got.EffectiveScoreLimit()))
+	}
+
+	for _, mode := range c.Modes {
+		if !got.HasMode(mode) {
+			diffs = append(diffs, fmt.Sprintf("%s: tidak ada", mode))
+		}
+	}
+	for _, mode := range got.Modes {
+		if !c.HasMode(mode) {
+			diffs = append(diffs, fmt.Sprintf("%s: tidak boleh ditambahkan", mode))
+		}
+	}
+
+	keys := make(map[string]bool)
+	for key := range c.Settings {
+		keys[key] = true
+	}
+	for key := range got.Settings {
+		keys[key] = true
+	}
+	sorted := make([]string, 0, len(keys))
+	for key := range keys {
+		sorted = append(sorted, key)
+	}
+	sort.Strings(sorted)
+
+	for _, key := range sorted {
+		want, inWant := c.Settings[key]
+		have, inGot := got.Settings[key]
+		switch {
+		case !inGot:
+			diffs = append(diffs, fmt.Sprintf("%s: tidak ada (seharusnya %s=%s)", key, key, want))
+		case !inWant:
+			diffs = append(diffs, fmt.Sprintf("%s: tidak boleh ditambahkan (%s=%s)", key, key, have))
+		case want != have:
+			diffs = append(diffs, fmt.Sprintf("%s: seharusnya %s, tertulis %s", key, want, have))
+		}
+	}
+	return diffs
+}
+
+func sameSet(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	x := append([]string(nil), a...)
+	y := append([]string(nil), b...)
+	sort.Strings(x)
+	sort.Strings(y)
+	for i := range x {
+		if x[i] != y[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestParseKotobaCommand(t *testing.T) {
+	tests := []struct {
+		cmd     string
+		want    KotobaCommand
+		wantErr bool
+	}{
+		{
+			cmd: "k!quiz jpdb300 20 hardcore nd mmq=10 dauq=1 font=5 atl=16 color=#f173ff size=100 effect=antiocr",
+			want: KotobaCommand{
+				Decks:      []string{"jpdb300"},
+				ScoreLimit: 20,
+				Modes:      []string{"hardcore", "nd"},
+				Settings: map[string]string{
+					"mmq": "10", "dauq": "1", "font": "5", "atl": "16",
+					"color": "#f173ff", "size": "100", "effect": "antiocr",
+				},
+			},
+		},
+		{
+			cmd: "k!quiz hiragana+katakana nd mmq=10",
+			want: KotobaCommand{
+				Decks:    []string{"hiragana", "katakana"},
+				Modes:    []string{"nd"},
+				Settings: map[string]string{"mmq": "10"},
+			},
+		},
+		{cmd: "k!quiz", wantErr: true},
+		{cmd: "quiz jpdb300 20", wantErr: true},
+		{cmd: "k!quiz jpdb300 20 25", wantErr: true},
+		{cmd: "k!quiz jpdb300 atl=16 atl=30", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseKotobaCommand(tt.cmd)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseKotobaCommand(%q) error = %v, wantErr %v", tt.cmd, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseKotobaCommand(%q) = %+v, want %+v", tt.cmd, got, tt.want)
+		}
+	}
+}
+
+func TestKotobaCommandJSON(t *testing.T) {
+	// Bentuk objek dan bentuk string lama harus menghasilkan command yang sama
+	object := `{"decks":["gn2"],"scoreLimit":15,"modes":["hardcore"],"settings":{"atl":"16","mmq":"10"}}`
+	legacy := `"k!quiz gn2 15 hardcore mmq=10 atl=16"`
+
+	var a, b KotobaCommand
+	if err := json.Unmarshal([]byte(object), &a); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal([]byte(legacy), &b); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(a, b) {
+		t.Fatalf("object form %+v differs from legacy form %+v", a, b)
+	}
+	if got, want := a.String(), "k!quiz gn2 15 hardcore mmq=10 atl=16"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+	if got, want := a.ResultTitle(), "JLPT N2 Grammar Quiz"; got != want {
+		t.Errorf("ResultTitle() = %q, want %q", got, want)
+	}
+
+	// Huruf besar di katalog dibaca sama seperti command yang diketik user
+	var upper KotobaCommand
+	if err := json.Unmarshal([]byte(`{"decks":["GN2"],"scoreLimit":15,"modes":["Hardcore"],"settings":{"ATL":"16","mmq":"10"}}`), &upper); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(upper, b) {
+		t.Errorf("upper-case object %+v differs from legacy form %+v", upper, b)
+	}
+	if diff := upper.Diff(b); len(diff) != 0 {
+		t.Errorf("Diff = %q, want none", diff)
+	}
+	var dup KotobaCommand
+	if err := json.Unmarshal([]byte(`{"decks":["gn2"],"settings":{"ATL":"16","atl":"30"}}`), &dup); err == nil {
+		t.Error("duplicate setting accepted")
+	}
+
+	var unknown KotobaCommand
+	if err := json.Unmarshal([]byte(`{"decks":["gn2"],"deckName":"x"}`), &unknown); err == nil {
+		t.Error("unknown field accepted")
+	}
+}
+
+func TestCompareReportedStartEmbed(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "kotoba", "start", "jpdb300_easier.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var embed discordgo.MessageEmbed
+	if err := json.Unmarshal(data, &embed); err != nil {
+		t.Fatal(err)
+	}
+	if !IsKotobaStartEmbed(&embed) {
+		t.Fatal("fixture not recognised as a start embed")
+	}
+
+	cmd, err := ParseKotobaCommand("k!quiz jpdb300 20 hardcore nd mmq=10 dauq=1 font=5 atl=16 color=#f173ff size=100 effect=antiocr")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// font dilaporkan sebagai nama, tidak bisa dicek; sisanya harus terbaca
+	want := []string{
+		"hardcore: seharusnya aktif, tercatat tidak aktif",
+		"atl: seharusnya 16, tercatat 30 seconds",
+		"effect: seharusnya antiocr, tercatat none",
+	}
+	if got := cmd.CompareReported(ParseKotobaSettings(&embed)); !reflect.DeepEqual(got, want) {
+		t.Errorf("CompareReported =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestSameSettingValue(t *testing.T) {
+	tests := []struct {
+		want, got string
+		same      bool
+	}{
+		{"16", "16 seconds", true},
+		{"16", "30 seconds", false},
+		{"5", "Noto Serif JP", true}, // tidak bisa dicek
+		{"antiocr", "antiocr", true},
+		{"antiocr", "Anti-OCR", true},
+		{"antiocr", "Anti OCR", true},
+		{"antiocr", "None", false},
+		{"#f173ff", "#F173FF", true},
+		{"#f173ff", "F173FF", true},
+		{"#f173ff", "#ffffff", false},
+	}
+	for _, tt := range tests {
+		if got := sameSettingValue(tt.want, tt.got); got != tt.same {
+			t.Errorf("sameSettingValue(%q, %q) = %v, want %v", tt.want, tt.got, got, tt.same)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+var updateGolden = flag.Bool("update", false, "tulis ulang file golden di testdata")
+
+// TestParseKotobaResultGolden membaca setiap embed di testdata/kotoba dan
+// membandingkan hasil parser dengan file .golden di sebelahnya.
+// Jalankan `go test -run Golden -update` setelah mengubah parser.
+func TestParseKotobaResultGolden(t *testing.T) {
+	fixtures, err := filepath.Glob(filepath.Join("testdata", "kotoba", "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fixtures) == 0 {
+		t.Fatal("no Kotoba fixtures found")
+	}
+
+	for _, fixture := range fixtures {
+		name := strings.TrimSuffix(filepath.Base(fixture), ".json")
+		t.Run(name, func(t *testing.T) {
+			data, err := os.ReadFile(fixture)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var embed discordgo.MessageEmbed
+			if err := json.Unmarshal(data, &embed); err != nil {
+				t.Fatalf("fixture is not a valid embed: %v", err)
+			}
+
+			// Tanpa hasil (bukan embed akhir) ditulis sebagai null
+			var result *KotobaResult
+			if r, ok := ParseKotobaResult(&embed); ok {
+				result = &r
+			}
+			var buf bytes.Buffer
+			enc := json.NewEncoder(&buf)
+			enc.SetEscapeHTML(false)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(result); err != nil {
+				t.Fatal(err)
+			}
+			got := buf.Bytes()
+
+			golden := strings.TrimSuffix(fixture, ".json") + ".golden"
+			if *updateGolden {
+				if err := os.WriteFile(golden, got, 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("missing golden file (run with -update): %v", err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("ParseKotobaResult mismatch for %s\n got: %s\nwant: %s", name, got, want)
+			}
+		})
+	}
+}
+
+func TestParseKotobaNames(t *testing.T) {
+	tests := []struct {
+		name        string
+		description string
+		scores      string
+		winner      string
+		participant KotobaParticipant
+	}{
+		{
+			name:        "name with dots",
+			description: "The score limit of 10 was reached by @john.doe. Congratulations!",
+			scores:      "john.doe has 10 points",
+			winner:      "john.doe",
+			participant: KotobaParticipant{Name: "john.doe", Score: 10},
+		},
+		{
+			name:        "name at end of line",
+			description: "The score limit of 10 was reached by @john.doe.",
+			scores:      "1. john.doe has 10 points",
+			winner:      "john.doe",
+			participant: KotobaParticipant{Name: "john.doe", Score: 10},
+		},
+		{
+			name:        "name starting with digits",
+			description: "The score limit of 10 was reached by @2pac. Congratulations!",
+			scores:      "🥇 2pac has 10 points",
+			winner:      "2pac",
+			participant: KotobaParticipant{Name: "2pac", Score: 10},
+		},
+		{
+			name:        "ranked mention",
+			description: "The score limit of 10 was reached by <@!100000000000000001>. Congratulations!",
+			scores:      "10) <@100000000000000001> has 10 points",
+			participant: KotobaParticipant{UserID: "100000000000000001", Score: 10},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, ok := ParseKotobaResult(&discordgo.MessageEmbed{
+				Title:       "jpdb300 Ended",
+				Description: tt.description,
+				Fields:      []*discordgo.MessageEmbedField{{Name: "Final Scores", Value: tt.scores}},
+			})
+			if !ok {
+				t.Fatal("result embed not recognised")
+			}
+			if result.WinnerName != tt.winner {
+				t.Errorf("WinnerName = %q, want %q", result.WinnerName, tt.winner)
+			}
+			if len(result.Participants) != 1 || result.Participants[0] != tt.participant {
+				t.Errorf("Participants = %+v, want [%+v]", result.Participants, tt.participant)
+			}
+		})
+	}
+}
+
+func TestParseKotobaQuestionCount(t *testing.T) {
+	// 60 baris soal sepanjang batas field Discord, dipotong di tengah baris
+	long := strings.Repeat("[賄賂](https://jisho.org/search/賄賂)\n", 60)
+	long = string([]rune(long)[:embedFieldValueLimit])
+
+	tests := []struct {
+		name      string
+		fields    []*discordgo.MessageEmbedField
+		questions int
+		atLeast   bool
+	}{
+		{"complete list", []*discordgo.MessageEmbedField{
+			{Name: "Unanswered Questions", Value: "一\n二\n三"},
+		}, 13, false},
+		{"ellipsis", []*discordgo.MessageEmbedField{
+			{Name: "Unanswered Questions", Value: "一\n二\n三\n…"},
+		}, 13, true},
+		{"cut at field limit", []*discordgo.MessageEmbedField{
+			{Name: "Unanswered Questions", Value: long},
+		}, 10 + strings.Count(long, "\n"), true},
+		{"total from Kotoba", []*discordgo.MessageEmbedField{
+			{Name: "Unanswered Questions", Value: "一\n二\n三..."},
+			{Name: "Questions", Value: "42"},
+		}, 42, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			embed := &discordgo.MessageEmbed{
+				Title:       "jpdb1k3k Ended",
+				Description: "Too many unanswered questions in a row. Stopping.",
+				Fields:      append([]*discordgo.MessageEmbedField{{Name: "Final Scores", Value: "<@100000000000000001> has 10 points"}}, tt.fields...),
+			}
+			result, ok := ParseKotobaResult(embed)
+			if !ok {
+				t.Fatal("not parsed as a result embed")
+			}
+			if result.Questions != tt.questions || result.QuestionsAtLeast != tt.atLeast {
+				t.Errorf("questions = %d (at least %v), want %d (at least %v)", result.Questions, result.QuestionsAtLeast, tt.questions, tt.atLeast)
+			}
+		})
+	}
+}
+
+func TestCaptureKotobaEmbed(t *testing.T) {
+	kotobaCaptureDir = t.TempDir()
+	t.Cleanup(func() { kotobaCaptureDir = "" })
+
+	captureKotobaEmbed("completed", &discordgo.MessageEmbed{
+		Title:       "jpdb300 Ended",
+		Description: "The score limit of 20 was reached by <@412345678901234567>. Congratulations!",
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Final Scores", Value: "<@412345678901234567> has 20 points\n<@598765432109876543> has 2 points"},
+			{Name: "Game Report", Value: "[View a report for this game](https://kotobaweb.com/dashboard/game_reports/66a1f0c2e4b0a1b2c3d4e5f6)"},
+		},
+	})
+
+	files, err := filepath.Glob(filepath.Join(kotobaCaptureDir, "completed-*.json"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("captured files = %v, %v", files, err)
+	}
+	data, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var embed discordgo.MessageEmbed
+	if err := json.Unmarshal(data, &embed); err != nil {
+		t.Fatalf("capture is not a valid embed: %v", err)
+	}
+	if want := "The score limit of 20 was reached by <@100000000000000001>. Congratulations!"; embed.Description != want {
+		t.Errorf("description = %q, want %q", embed.Description, want)
+	}
+	if want := "<@100000000000000001> has 20 points\n<@100000000000000002> has 2 points"; embed.Fields[0].Value != want {
+		t.Errorf("scores = %q, want %q", embed.Fields[0].Value, want)
+	}
+	if !strings.Contains(embed.Fields[1].Value, "game_reports/000000000000000000000001)") {
+		t.Errorf("report = %q", embed.Fields[1].Value)
+	}
+}
@@ -5,23 +5,45 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/joho/godotenv"
 )
 
 var (
-	activeQuizzes = make(map[string]QuizSession) // userID -> QuizSession
-	kotobaBotID   = "251239170058616833"
+	sessionStore SessionStore = NewFileSessionStore("sessions.json")
+	sessions                  = NewSessionManager(sessionStore)
+	history      HistoryStore = NewFileHistoryStore("history.jsonl")
+	kotobaBotID               = "251239170058616833"
 )
 
 type QuizSession struct {
-	UserID    string
-	QuizID    string
-	ThreadID  string
-	ChannelID string
-	Started   bool
-	Progress  int
+	GuildID   string   `json:"guildId,omitempty"`
+	UserID    string   `json:"userId"`
+	QuizID    string   `json:"quizId"`
+	Quiz      QuizInfo `json:"quiz"` // definisi quiz saat sesi dibuat, tidak ikut berubah saat reload
+	ThreadID  string   `json:"threadId"`
+	ChannelID string   `json:"channelId"`
+	Started   bool     `json:"started"`
+	Progress  int      `json:"progress"`
+
+	// Waktu channel quiz dibuat dan hasil Kotoba per tahap, untuk riwayat
+	CreatedAt time.Time      `json:"createdAt,omitzero"`
+	StageLog  []StageAttempt `json:"stageLog,omitempty"`
+
+	// Waktu command tahap ini di-paste, untuk batas waktu tahap
+	StageStartedAt time.Time `json:"stageStartedAt,omitzero"`
+
+	// Perbedaan setting yang dilaporkan Kotoba saat tahap ini dimulai
+	SettingsMismatch []string `json:"settingsMismatch,omitempty"`
+
+	// Cooldown level setelah tahap ini gagal; command baru ditolak sampai
+	// waktu ini lewat
+	RetryAfter time.Time `json:"retryAfter,omitzero"`
+
+	// Tempat quiz adalah thread private, bukan channel
+	InThread bool `json:"inThread,omitempty"`
 }
 
 func main() {
@@ -30,6 +52,32 @@ func main() {
 		log.Fatal("Error loading .env file")
 	}
 
+	if path := os.Getenv("QUIZ_CATALOG"); path != "" {
+		quizCatalogPath = path
+	}
+	if path := os.Getenv("GUILD_CONFIG"); path != "" {
+		guildConfigPath = path
+	}
+	catalog, err := ReloadQuizCatalog()
+	if err != nil {
+		log.Fatalf("Gagal memuat katalog quiz: %v", err)
+	}
+	log.Printf("Katalog quiz dimuat: %d level dari %s", len(catalog.Quizzes), quizCatalogPath)
+
+	if path := os.Getenv("SESSION_STORE"); path != "" {
+		sessionStore = NewFileSessionStore(path)
+		sessions = NewSessionManager(sessionStore)
+	}
+	// Sesi yang selesai membuka slot untuk user di antrian
+	sessions.OnDelete(provisioner.SessionClosed)
+	if path := os.Getenv("HISTORY_STORE"); path != "" {
+		history = NewFileHistoryStore(path)
+	}
+	if path := os.Getenv("OVERFLOW_CATEGORY_STORE"); path != "" {
+		overflowCategories = NewOverflowCategoryStore(path)
+	}
+	kotobaCaptureDir = os.Getenv("KOTOBA_CAPTURE_DIR")
+
 	token := os.Getenv("DISCORD_TOKEN")
 	if token == "" {
 		log.Fatal("DISCORD_TOKEN is not set")
@@ -41,9 +89,10 @@ func main() {
 	}
 
 	// Register event handlers
-	dg.AddHandler(OnReady)
-	dg.AddHandler(OnInteraction)
-	dg.AddHandler(OnMessageCreate)
+	s := gatewaySession{dg}
+	dg.AddHandler(func(_ *discordgo.Session, r *discordgo.Ready) { OnReady(s, r) })
+	dg.AddHandler(func(_ *discordgo.Session, i *discordgo.InteractionCreate) { OnInteraction(s, i) })
+	dg.AddHandler(func(_ *discordgo.Session, m *discordgo.MessageCreate) { OnMessageCreate(s, m) })
 
 	// Set intents
 	dg.Identify.Intents = discordgo.IntentsGuilds | 
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Warna embed mod-log per jenis kejadian
+const (
+	modLogColorUpgrade  = 0x57f287
+	modLogColorNoChange = 0x99aab5
+	modLogColorRejected = 0xfee75c
+	modLogColorCleared  = 0x5865f2
+	modLogColorError    = 0xed4245
+)
+
+// modLogEvent adalah satu perubahan role (atau percobaan perubahan) yang
+// dilaporkan ke channel mod-log guild.
+type modLogEvent struct {
+	Title       string
+	Color       int
+	UserID      string
+	Quiz        *QuizInfo // nil untuk tindakan moderator
+	OldRoles    []string
+	NewRoles    []string
+	ResultURL   string // link hasil Kotoba
+	ModeratorID string
+	Reason      string
+	Error       string
+}
+
+// postModLog mengirim embed kejadian ke channel mod-log. Tidak melakukan
+// apa-apa kalau guild tidak punya mod-log.
+func postModLog(s Discord, cfg *GuildConfig, e modLogEvent) {
+	if cfg.ModLogChannelID == "" {
+		return
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:     e.Title,
+		Color:     e.Color,
+		Timestamp: time.Now().Format(time.RFC3339),
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "User", Value: fmt.Sprintf("<@%s> (`%s`)", e.UserID, e.UserID), Inline: true},
+		},
+	}
+	field := func(name, value string) {
+		if value != "" {
+			embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: name, Value: value, Inline: true})
+		}
+	}
+	if e.Quiz != nil {
+		field("Level", fmt.Sprintf("%s (level %d)", e.Quiz.Label, e.Quiz.Level))
+	}
+	field("Role lama", roleMentions(e.OldRoles))
+	field("Role baru", roleMentions(e.NewRoles))
+	if e.ResultURL != "" {
+		field("Hasil Kotoba", fmt.Sprintf("[Lihat hasil](%s)", e.ResultURL))
+	}
+	if e.ModeratorID != "" {
+		field("Moderator", "<@"+e.ModeratorID+">")
+	}
+	if e.Reason != "" {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Alasan", Value: truncateRunes(e.Reason, 1024)})
+	}
+	if e.Error != "" {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Error", Value: truncateRunes(e.Error, 1024)})
+	}
+
+	_, err := s.ChannelMessageSendComplex(cfg.ModLogChannelID, &discordgo.MessageSend{
+		Embeds:          []*discordgo.MessageEmbed{embed},
+		AllowedMentions: &discordgo.MessageAllowedMentions{}, // hanya tampilan, tidak ada yang di-ping
+	})
+	if err != nil {
+		log.Printf("Gagal kirim mod-log ke %s: %v", cfg.ModLogChannelID, err)
+	}
+}
+
+// logRoleError melaporkan panggilan role ke Discord yang gagal.
+func logRoleError(s Discord, cfg *GuildConfig, userID string, quiz *QuizInfo, action, roleID string, err error) {
+	e := modLogEvent{
+		Title:  "⚠️ Gagal " + action + " role",
+		Color:  modLogColorError,
+		UserID: userID,
+		Quiz:   quiz,
+		Error:  err.Error(),
+	}
+	if action == "mencabut" {
+		e.OldRoles = []string{roleID}
+	} else {
+		e.NewRoles = []string{roleID}
+	}
+	postModLog(s, cfg, e)
+}
+
+// kotobaResultURL mengembalikan link hasil Kotoba: halaman laporan dari
+// embed kalau ada, kalau tidak link ke pesannya.
+func kotobaResultURL(m *discordgo.MessageCreate) string {
+	for _, embed := range m.Embeds {
+		if embed.URL != "" {
+			return embed.URL
+		}
+	}
+	return fmt.Sprintf("https://discord.com/channels/%s/%s/%s", m.GuildID, m.ChannelID, m.ID)
+}
+
+func roleMentions(roleIDs []string) string {
+	list := make([]string, 0, len(roleIDs))
+	for _, id := range roleIDs {
+		if id != "" {
+			list = append(list, "<@&"+id+">")
+		}
+	}
+	return strings.Join(list, ", ")
+}
+
+func truncateRunes(s string, limit int) string {
+	if runes := []rune(s); len(runes) > limit {
+		return string(runes[:limit-4]) + " ..."
+	}
+	return s
+}
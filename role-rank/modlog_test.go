@@ -0,0 +1,202 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// modLogEmbeds mengembalikan embed yang dikirim ke channel mod-log.
+func modLogEmbeds(f *fakeGuild) []*discordgo.MessageEmbed {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var list []*discordgo.MessageEmbed
+	for _, msg := range f.messages["mod-log"] {
+		list = append(list, msg.Embeds...)
+	}
+	return list
+}
+
+func embedField(embed *discordgo.MessageEmbed, name string) string {
+	for _, field := range embed.Fields {
+		if field.Name == name {
+			return field.Value
+		}
+	}
+	return ""
+}
+
+func TestModLog(t *testing.T) {
+	const userID, moderatorID = "100000000000000001", "100000000000000009"
+	setup := func(t *testing.T) (*fakeGuild, *GuildConfig) {
+		f, cfg := setupFlowTest(t)
+		f.addChannel(&discordgo.Channel{ID: "mod-log", Type: discordgo.ChannelTypeGuildText})
+		cfg.ModLogChannelID = "mod-log"
+		return f, cfg
+	}
+
+	t.Run("upgrade", func(t *testing.T) {
+		f, cfg := setup(t)
+		f.addMember(userID, quizRole(t, cfg, "Level_1"))
+		passQuiz(t, f, cfg, userID, "Level_2")
+
+		embeds := modLogEmbeds(f)
+		if len(embeds) != 1 {
+			t.Fatalf("mod-log has %d embeds, want 1", len(embeds))
+		}
+		e := embeds[0]
+		if !strings.Contains(e.Title, "naik level") {
+			t.Errorf("title = %q", e.Title)
+		}
+		want := map[string]string{
+			"User":      "<@" + userID + ">",
+			"Role lama": "<@&" + quizRole(t, cfg, "Level_1") + ">",
+			"Role baru": "<@&" + quizRole(t, cfg, "Level_2") + ">",
+			"Level":     "(level ",
+		}
+		for name, value := range want {
+			if got := embedField(e, name); !strings.Contains(got, value) {
+				t.Errorf("field %s = %q, want %q", name, got, value)
+			}
+		}
+		if got := embedField(e, "Hasil Kotoba"); !strings.Contains(got, "https://discord.com/channels/"+testGuildID+"/") {
+			t.Errorf("result link = %q", got)
+		}
+	})
+
+	t.Run("cumulative upgrade removes nothing", func(t *testing.T) {
+		f, cfg := setup(t)
+		cfg.RoleStrategy = RoleCumulative
+		f.addMember(userID, quizRole(t, cfg, "Level_1"))
+		passQuiz(t, f, cfg, userID, "Level_2")
+
+		embeds := modLogEmbeds(f)
+		if len(embeds) != 1 || !strings.Contains(embeds[0].Title, "naik level") {
+			t.Fatalf("mod-log embeds = %+v", embeds)
+		}
+		if got := embedField(embeds[0], "Role lama"); got != "" {
+			t.Errorf("old roles = %q, want none removed", got)
+		}
+		if got := embedField(embeds[0], "Role baru"); got != "<@&"+quizRole(t, cfg, "Level_2")+">" {
+			t.Errorf("new roles = %q", got)
+		}
+	})
+
+	t.Run("same level", func(t *testing.T) {
+		f, cfg := setup(t)
+		f.addMember(userID, quizRole(t, cfg, "Level_2"))
+		passQuiz(t, f, cfg, userID, "Level_2")
+
+		embeds := modLogEmbeds(f)
+		if len(embeds) != 1 || !strings.Contains(embeds[0].Title, "tidak berubah") {
+			t.Fatalf("mod-log embeds = %+v", embeds)
+		}
+	})
+
+	t.Run("downgrade", func(t *testing.T) {
+		f, cfg := setup(t)
+		f.addMember(userID, quizRole(t, cfg, "Level_3"))
+		passQuiz(t, f, cfg, userID, "Level_2")
+
+		embeds := modLogEmbeds(f)
+		if len(embeds) != 1 || !strings.Contains(embeds[0].Title, "Downgrade") {
+			t.Fatalf("mod-log embeds = %+v", embeds)
+		}
+		if got := embedField(embeds[0], "Role lama"); got != "<@&"+quizRole(t, cfg, "Level_3")+">" {
+			t.Errorf("old role = %q", got)
+		}
+	})
+
+	t.Run("failed role call", func(t *testing.T) {
+		f, cfg := setup(t)
+		f.addMember(userID, quizRole(t, cfg, "Level_1"))
+		f.roleErrors[quizRole(t, cfg, "Level_1")] = errors.New("missing permissions")
+		passQuiz(t, f, cfg, userID, "Level_2")
+
+		var titles []string
+		for _, e := range modLogEmbeds(f) {
+			titles = append(titles, e.Title)
+			if strings.Contains(e.Title, "Gagal mencabut") && embedField(e, "Error") != "missing permissions" {
+				t.Errorf("error field = %q", embedField(e, "Error"))
+			}
+		}
+		if len(titles) != 2 || !strings.Contains(titles[0], "Gagal mencabut") || !strings.Contains(titles[1], "naik level") {
+			t.Errorf("mod-log titles = %q", titles)
+		}
+	})
+
+	t.Run("moderator clear", func(t *testing.T) {
+		f, cfg := setup(t)
+		f.addMember(userID, quizRole(t, cfg, "Level_2"))
+		clearQuizRoles(f, cfg, testGuildID, moderatorID, userID, "akun kedua")
+
+		embeds := modLogEmbeds(f)
+		if len(embeds) != 1 {
+			t.Fatalf("mod-log has %d embeds, want 1", len(embeds))
+		}
+		want := map[string]string{
+			"Moderator": "<@" + moderatorID + ">",
+			"Alasan":    "akun kedua",
+			"Role lama": "<@&" + quizRole(t, cfg, "Level_2") + ">",
+		}
+		for name, value := range want {
+			if got := embedField(embeds[0], name); got != value {
+				t.Errorf("field %s = %q, want %q", name, got, value)
+			}
+		}
+	})
+
+	t.Run("reconcile", func(t *testing.T) {
+		f, cfg := setup(t)
+		l1, l2, l4 := quizRole(t, cfg, "Level_1"), quizRole(t, cfg, "Level_2"), quizRole(t, cfg, "Level_4")
+		f.addMember(userID, l1, l4)
+		f.addMember("100000000000000002", l2)
+		f.addMember("100000000000000003", l1, l2)
+		f.roleErrors[l1] = errors.New("missing permissions")
+		f.addMember("100000000000000004", l2, l4)
+		reconcileQuizRoles(f, cfg, testGuildID, moderatorID, "")
+
+		// Satu embed per member yang dirapikan, satu per role yang gagal
+		var titles []string
+		for _, e := range modLogEmbeds(f) {
+			titles = append(titles, e.Title+" "+embedField(e, "User"))
+		}
+		sort.Strings(titles)
+		want := []string{
+			"⚠️ Gagal mencabut role <@" + userID + "> (`" + userID + "`)",
+			"⚠️ Gagal mencabut role <@100000000000000003> (`100000000000000003`)",
+			"🧹 Role level ganda dirapikan <@100000000000000004> (`100000000000000004`)",
+		}
+		if !reflect.DeepEqual(titles, want) {
+			t.Fatalf("mod-log = %q\nwant %q", titles, want)
+		}
+		for _, e := range modLogEmbeds(f) {
+			if !strings.Contains(e.Title, "dirapikan") {
+				continue
+			}
+			fields := map[string]string{
+				"Role lama": "<@&" + l2 + ">",
+				"Role baru": "<@&" + l4 + ">",
+				"Moderator": "<@" + moderatorID + ">",
+			}
+			for name, value := range fields {
+				if got := embedField(e, name); got != value {
+					t.Errorf("field %s = %q, want %q", name, got, value)
+				}
+			}
+		}
+	})
+
+	t.Run("no mod-log channel", func(t *testing.T) {
+		f, cfg := setupFlowTest(t)
+		f.addMember(userID, quizRole(t, cfg, "Level_1"))
+		passQuiz(t, f, cfg, userID, "Level_2")
+		if embeds := modLogEmbeds(f); len(embeds) != 0 {
+			t.Errorf("mod-log embeds without configured channel: %+v", embeds)
+		}
+	})
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// checkPrerequisites memastikan member boleh memilih quiz ini. Hasilnya
+// pesan penolakan yang menyebut role yang dibutuhkan, kosong kalau boleh.
+func checkPrerequisites(cfg *GuildConfig, catalog *QuizCatalog, quiz QuizInfo, member *discordgo.Member) string {
+	hasQuizRole := func(quizID string) bool {
+		req, ok := catalog.Quizzes[quizID]
+		return ok && cfg.HasPassed(member, req)
+	}
+	needRole := func(quizID string) string {
+		req := catalog.Quizzes[quizID]
+		return fmt.Sprintf("Kamu harus memiliki role <@&%s> (**%s**) sebelum mengambil quiz **%s**.", cfg.RoleID(req), req.Label, quiz.Label)
+	}
+
+	if cfg.StrictLadder {
+		if prev, ok := previousLevel(catalog, quiz); ok && !hasQuizRole(prev) {
+			return needRole(prev)
+		}
+	}
+
+	if quiz.Requires == nil {
+		return ""
+	}
+	if req := quiz.Requires.Quiz; req != "" && !hasQuizRole(req) {
+		return needRole(req)
+	}
+	if minLevel := quiz.Requires.MinLevel; minLevel != nil {
+		for _, key := range catalog.Order {
+			q := catalog.Quizzes[key]
+			if q.Level >= *minLevel && hasQuizRole(key) {
+				return ""
+			}
+		}
+		// Sebut role terendah yang memenuhi syarat
+		for _, key := range catalog.Order {
+			if catalog.Quizzes[key].Level >= *minLevel {
+				req := catalog.Quizzes[key]
+				return fmt.Sprintf("Kamu harus memiliki role level %d atau lebih tinggi (mulai dari <@&%s> **%s**) sebelum mengambil quiz **%s**.",
+					*minLevel, cfg.RoleID(req), req.Label, quiz.Label)
+			}
+		}
+	}
+	return ""
+}
+
+// previousLevel mengembalikan quiz satu tingkat di bawah quiz ini menurut
+// urutan level katalog.
+func previousLevel(catalog *QuizCatalog, quiz QuizInfo) (string, bool) {
+	prev, found := "", false
+	for _, key := range catalog.Order {
+		if catalog.Quizzes[key].Level >= quiz.Level {
+			break
+		}
+		prev, found = key, true
+	}
+	return prev, found
+}
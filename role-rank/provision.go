@@ -0,0 +1,172 @@
+package main
+
+import (
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// Jumlah channel quiz yang dibuat bersamaan. Pembuatan channel butuh
+	// beberapa request REST, jadi dibatasi supaya tidak kena rate limit.
+	provisionWorkers = 2
+	// Request yang sudah diterima tapi belum dikerjakan worker
+	provisionQueueSize = 64
+	// Panjang antrian per guild kalau maxQueueLength tidak diisi
+	defaultMaxQueueLength = 50
+)
+
+var provisioner = NewProvisioner(provisionWorkers, provisionQueueSize, provisionQuiz)
+
+// provisionRequest adalah permintaan membuat tempat quiz untuk satu user.
+// User sudah di-Reserve di sessions sebelum request dibuat. Konfigurasi
+// guild dan definisi quiz baru dibaca saat request dikerjakan, supaya
+// reload selama user menunggu di antrian ikut terpakai.
+type provisionRequest struct {
+	s           Discord
+	interaction *discordgo.InteractionCreate
+	guildID     string
+	user        *discordgo.User
+	quizID      string
+	queued      bool // sempat menunggu karena batas quiz terbuka
+}
+
+// SubmitResult adalah hasil Submit.
+type SubmitResult int
+
+const (
+	SubmitAccepted  SubmitResult = iota // langsung dikerjakan worker
+	SubmitQueued                        // menunggu slot quiz terbuka
+	SubmitQueueFull                     // antrian penuh, request ditolak
+)
+
+// Provisioner mengatur pembuatan tempat quiz: membatasi jumlah quiz yang
+// terbuka per guild, menyimpan antrian user yang menunggu, dan membuat
+// channel lewat sejumlah kecil worker.
+type Provisioner struct {
+	mu       sync.Mutex
+	jobs     chan *provisionRequest
+	waiting  map[string][]*provisionRequest // guildID -> antrian, terlama duluan
+	admitted map[string]int                 // guildID -> request di jobs/worker yang belum jadi sesi
+	busy     sync.WaitGroup
+	run      func(req *provisionRequest)
+}
+
+// NewProvisioner menjalankan worker yang memanggil run untuk setiap
+// request yang diterima.
+func NewProvisioner(workers, queueSize int, run func(req *provisionRequest)) *Provisioner {
+	p := &Provisioner{
+		jobs:     make(chan *provisionRequest, queueSize),
+		waiting:  make(map[string][]*provisionRequest),
+		admitted: make(map[string]int),
+		run:      run,
+	}
+	for n := 0; n < workers; n++ {
+		go p.work()
+	}
+	return p
+}
+
+// Submit memasukkan request. Untuk SubmitQueued, hasil kedua adalah posisi
+// user di antrian (mulai dari 1).
+func (p *Provisioner) Submit(req *provisionRequest) (SubmitResult, int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	queue := p.waiting[req.guildID]
+	if len(queue) == 0 && p.hasRoom(req.guildID) {
+		if !p.dispatch(req) {
+			return SubmitQueueFull, 0
+		}
+		return SubmitAccepted, 0
+	}
+
+	limit := defaultMaxQueueLength
+	if cfg, ok := GuildConfigFor(req.guildID); ok {
+		limit = cfg.QueueLimit()
+	}
+	if len(queue) >= limit {
+		return SubmitQueueFull, 0
+	}
+	req.queued = true
+	p.waiting[req.guildID] = append(queue, req)
+	return SubmitQueued, len(queue) + 1
+}
+
+// Position mengembalikan posisi user di antrian guild, 0 kalau tidak ada.
+func (p *Provisioner) Position(guildID, userID string) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for n, req := range p.waiting[guildID] {
+		if req.user.ID == userID {
+			return n + 1
+		}
+	}
+	return 0
+}
+
+// SessionClosed dipanggil saat sesi quiz selesai supaya user berikutnya di
+// antrian bisa masuk.
+func (p *Provisioner) SessionClosed(session QuizSession) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.admit(session.GuildID)
+}
+
+// Wait menunggu semua request yang sudah diterima selesai dikerjakan.
+func (p *Provisioner) Wait() {
+	p.busy.Wait()
+}
+
+func (p *Provisioner) work() {
+	for req := range p.jobs {
+		p.run(req)
+
+		// Sesi sudah dibuat (atau gagal), slot dihitung dari sessions lagi
+		p.mu.Lock()
+		p.admitted[req.guildID]--
+		p.admit(req.guildID)
+		p.mu.Unlock()
+		p.busy.Done()
+	}
+}
+
+// hasRoom cek batas quiz terbuka guild. Guild yang hilang dari
+// konfigurasi dianggap tanpa batas; request-nya ditolak oleh worker.
+// Dipanggil dengan mu terkunci.
+func (p *Provisioner) hasRoom(guildID string) bool {
+	cfg, ok := GuildConfigFor(guildID)
+	if !ok || cfg.MaxOpenQuizzes == 0 {
+		return true
+	}
+	return sessions.CountGuild(guildID)+p.admitted[guildID] < cfg.MaxOpenQuizzes
+}
+
+// dispatch mengirim request ke worker tanpa menunggu. Dipanggil dengan mu
+// terkunci.
+func (p *Provisioner) dispatch(req *provisionRequest) bool {
+	p.busy.Add(1)
+	select {
+	case p.jobs <- req:
+		p.admitted[req.guildID]++
+		return true
+	default:
+		p.busy.Done()
+		return false
+	}
+}
+
+// admit memindahkan user dari antrian ke worker selama masih ada slot.
+// Dipanggil dengan mu terkunci.
+func (p *Provisioner) admit(guildID string) {
+	for len(p.waiting[guildID]) > 0 {
+		req := p.waiting[guildID][0]
+		if !p.hasRoom(guildID) || !p.dispatch(req) {
+			return
+		}
+		p.waiting[guildID] = p.waiting[guildID][1:]
+	}
+	delete(p.waiting, guildID)
+}
@@ -1,153 +1,119 @@
 package main
 
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
 type QuizInfo struct {
-	Label       string
-	Description string
-	Value       string
-	RoleID      string
-	Commands    []string
-	DeckNames   []string
-	ScoreLimits []string
-	Level       int
+	Label       string  `json:"label"`
+	Description string  `json:"description"`
+	Value       string  `json:"value"`
+	RoleID      string  `json:"roleId"`
+	Stages      []Stage `json:"stages"` // dikerjakan berurutan, role diberikan setelah tahap terakhir
+	Level       int     `json:"level"`
+
+	// Jeda sebelum level ini bisa dicoba lagi setelah percobaan yang gagal
+	// atau ditinggalkan, 0 = tanpa jeda
+	Cooldown Duration `json:"cooldown,omitzero"`
+
+	// Role yang harus dimiliki sebelum level ini bisa dipilih
+	Requires *Prerequisite `json:"requires,omitempty"`
+}
+
+// Prerequisite adalah syarat role sebelum sebuah level bisa dipilih.
+// Kalau keduanya diisi, keduanya harus terpenuhi.
+type Prerequisite struct {
+	Quiz     string `json:"quiz,omitempty"`     // harus sedang memegang role quiz ini
+	MinLevel *int   `json:"minLevel,omitempty"` // harus memegang role dengan level minimal ini
+}
+
+// Stage adalah satu run Kotoba yang harus diselesaikan dalam sebuah quiz.
+type Stage struct {
+	Command   KotobaCommand `json:"command"`
+	Deck      string        `json:"deck,omitempty"`     // judul deck di embed hasil, default dari command
+	TimeLimit Duration      `json:"timeLimit,omitzero"` // batas waktu sejak command di-paste, 0 = tanpa batas
+	Pass      *PassCriteria `json:"pass,omitempty"`     // syarat lulus tambahan selain score limit
+}
+
+// PassCriteria adalah syarat lulus tambahan untuk satu tahap.
+type PassCriteria struct {
+	MaxQuestions int `json:"maxQuestions,omitempty"` // score limit harus tercapai dalam sekian soal
+}
+
+// Stage mengembalikan tahap ke-n, false kalau di luar jangkauan.
+func (q QuizInfo) Stage(n int) (Stage, bool) {
+	if n < 0 || n >= len(q.Stages) {
+		return Stage{}, false
+	}
+	return q.Stages[n], true
 }
 
+// ExpectedDeck adalah judul deck yang harus muncul di embed hasil Kotoba.
+func (st Stage) ExpectedDeck() string {
+	if st.Deck != "" {
+		return st.Deck
+	}
+	return st.Command.ResultTitle()
+}
+
+// ScoreLimit adalah score limit yang harus dilaporkan Kotoba.
+func (st Stage) ScoreLimit() int {
+	return st.Command.EffectiveScoreLimit()
+}
+
+// CheckPass cek syarat lulus tambahan. Hasilnya alasan gagal, kosong
+// kalau lulus. Jumlah soal yang hanya batas bawah baru ditolak kalau batas
+// bawahnya sudah melebihi maxQuestions.
+func (st Stage) CheckPass(result KotobaResult) string {
+	if st.Pass == nil {
+		return ""
+	}
+	if st.Pass.MaxQuestions > 0 && result.Questions > st.Pass.MaxQuestions {
+		return fmt.Sprintf("score limit harus tercapai dalam %d soal, quiz ini memakai %s soal", st.Pass.MaxQuestions, result.QuestionCount())
+	}
+	return ""
+}
+
+// Validate memastikan tahap bisa dipakai di katalog.
+func (st Stage) Validate() error {
+	if err := st.Command.Validate(); err != nil {
+		return err
+	}
+	if st.TimeLimit.Duration < 0 {
+		return fmt.Errorf("timeLimit %s tidak boleh negatif", st.TimeLimit)
+	}
+	if st.Pass != nil {
+		if st.Pass.MaxQuestions < 0 {
+			return fmt.Errorf("pass.maxQuestions %d tidak boleh negatif", st.Pass.MaxQuestions)
+		}
+		if st.Pass.MaxQuestions > 0 && st.Pass.MaxQuestions < st.ScoreLimit() {
+			return fmt.Errorf("pass.maxQuestions %d lebih kecil dari score limit %d", st.Pass.MaxQuestions, st.ScoreLimit())
+		}
+	}
+	return nil
+}
+
+// Duration adalah time.Duration yang ditulis sebagai string di JSON,
+// mis. "15m" atau "1h30m".
+type Duration struct {
+	time.Duration
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
 
-var Quizzes = map[string]QuizInfo{
-	"hiragana_katakana": {
-		Label:       "Kanji Wakaran (漢字わからん)",
-		Level:       0,
-		Description: "Hiragana + Katakana Quiz",
-		Value:       "hiragana_katakana",
-		RoleID:      "1392065087216291891", // Replace with actual role ID
-		Commands: []string{
-		    "k!quiz hiragana+katakana nd mmq=10 dauq=1 font=5 atl=16 color=#f173ff size=100",
-		},
-		DeckNames: []string{
-			"Multiple Deck Quiz",
-		},
-		ScoreLimits: []string{
-			"10",
-		},
-	},
-	"Level_1": {
-		Label:       "Shoshinsha (初心者)",
-		Level:       1,
-		Description: "JPDB Beginner Level (1-300)",
-		Value:       "Level_1",
-		RoleID:      "1392065395984306246", // Replace with actual role ID
-		Commands: []string{    
-			"k!quiz jpdb300 20 hardcore nd mmq=10 dauq=1 font=5 atl=16 color=#f173ff size=100 effect=antiocr",
-		},
-		DeckNames: []string{
-			"jpdb300",
-		},
-		ScoreLimits: []string{
-			"20",
-		},
-	},
-	"Level_2": {
-		Label:       "Gakushūsha (学習者)",
-		Level:       2,
-		Description: "JPDB Intermediate Level (300-1000)",
-		Value:       "Level_2",
-		RoleID:      "1392065532051591240", // Replace with actual role ID
-		Commands: []string{    
-			"k!quiz jpdb300to1k 25 hardcore nd mmq=10 dauq=1 font=5 atl=16 color=#f173ff size=100 effect=antiocr",
-		},
-		DeckNames: []string{
-			"jpdb300to1k",
-		},
-		ScoreLimits: []string{
-			"25",
-		},
-	},
-	"Level_3": {
-		Label:       "Jōkyūsha (上級者)",
-		Level:       3,
-		Description: "JPDB Advance Level (100-3000)",
-		Value:       "Level_3",
-		RoleID:      "1392065673185857627", // Replace with actual role ID
-		Commands: []string{    
-			"k!quiz jpdb1k3k 30 hardcore nd mmq=10 dauq=1 font=5 atl=16 color=#f173ff size=100 effect=antiocr",
-		},
-		DeckNames: []string{
-			"jpdb1k3k",
-		},
-		ScoreLimits: []string{
-			"30",
-		},
-	},
-	"Level_4": {
-		Label:       "Senpai (先輩)",
-		Level:       4,
-		Description: "JPDB 5000 + gn2",
-		Value:       "Level_4",
-		RoleID:      "1392066020235153408",
-		Commands: []string{
-			"k!quiz gn2 nd 20 mmq=4 atl=60",
-			"k!quiz jpdb3k5k 35 hardcore nd mmq=10 dauq=1 font=5 atl=16 color=#f173ff size=100 effect=antiocr",
-		},
-		DeckNames: []string{
-			"JLPT N2 Grammar Quiz",
-			"jpdb3k5k",
-		},
-		ScoreLimits: []string{
-			"20",
-			"35",
-		},
-	},
-	"Level_5": {
-		Label:       "Tetsujin (鉄人)",
-		Level:       5,
-		Description: "JPDB 10K + gn1",
-		Value:       "Level_5",
-		RoleID:      "1392066105677189121", // Replace with actual role ID
-		Commands: []string{
-		    "k!quiz gn1 nd 20 mmq=4 atl=60",
-			"k!quiz jpdb5k10k 40 hardcore nd mmq=10 dauq=1 font=5 atl=16 color=#f173ff size=100 effect=antiocr",
-		},
-		DeckNames: []string{
-			"JLPT N1 Grammar Quiz",
-			"jpdb5k10k",
-		},
-		ScoreLimits: []string{
-			"20",
-			"40",
-		},
-	},
-	"Level_6": {
-		Label:       "Kotodama (言霊)",
-		Level:       6,
-		Description: "JPDB 20K + gn1",
-		Value:       "Level_6",
-		RoleID:      "1392066278335840376", // Replace with actual role ID
-		Commands: []string{
-		    "k!quiz gn1 nd 20 mmq=4 atl=60",
-			"k!quiz jpdb10k20k 45 hardcore nd mmq=10 dauq=1 font=5 atl=16 color=#f173ff size=100 effect=antiocr",
-		},
-		DeckNames: []string{
-			"JLPT N1 Grammar Quiz",
-			"jpdb10k20k",
-		},
-		ScoreLimits: []string{
-			"20",
-			"45",
-		},
-	},
-	"Level_7": {
-		Label:       "Koten Kami (古典神)",
-		Level:       7,
-		Description: "JPDB 30K",
-		Value:       "Level_7",
-		RoleID:      "1392066430467440742", // Replace with actual role ID
-		Commands: []string{     
-			"k!quiz jpdb20k30k+haado+cope+kunyomi1kfull+loli+Myouji+jpdefs+places_full 50 nd hardcore dauq=1 font=5 atl=16 mmq=9 color=#f173ff size=100 effect=antiocr",
-		},
-		DeckNames: []string{
-			"Multiple Deck Quiz",
-		},
-		ScoreLimits: []string{
-			"50",
-		},
-	},
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var raw string
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return fmt.Errorf("durasi harus berupa string, mis. \"15m\": %w", err)
+	}
+	parsed, err := time.ParseDuration(raw)
+	if err != nil {
+		return err
+	}
+	d.Duration = parsed
+	return nil
 }
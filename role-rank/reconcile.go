@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Jumlah member per request saat merapikan seluruh guild (maksimum Discord)
+const reconcilePageSize = 1000
+
+// reconcileQuizRoles merapikan member yang memegang lebih dari satu role
+// level, mis. setelah role diberikan manual oleh moderator. Hanya role
+// level tertinggi yang disimpan, kecuali strategi guild cumulative.
+// targetUserID kosong berarti semua member guild. Hasilnya pesan ringkasan
+// untuk moderator.
+func reconcileQuizRoles(s Discord, cfg *GuildConfig, guildID, moderatorID, targetUserID string) string {
+	if cfg.Strategy() == RoleCumulative {
+		return "Server ini memakai strategi role cumulative, member memang boleh memegang beberapa role level."
+	}
+
+	var members []*discordgo.Member
+	if targetUserID != "" {
+		member, err := s.GuildMember(guildID, targetUserID)
+		if err != nil {
+			log.Printf("Gagal menemukan user %s: %v", targetUserID, err)
+			return "Gagal menemukan user."
+		}
+		members = append(members, member)
+	} else {
+		// Daftar member butuh intent Server Members di Developer Portal
+		after := ""
+		for {
+			page, err := s.GuildMembers(guildID, after, reconcilePageSize)
+			if err != nil {
+				log.Printf("Gagal mengambil daftar member guild %s: %v", guildID, err)
+				return "Gagal mengambil daftar member. Pastikan intent **Server Members** aktif untuk bot ini, atau pilih satu member."
+			}
+			members = append(members, page...)
+			if len(page) < reconcilePageSize {
+				break
+			}
+			after = page[len(page)-1].User.ID
+		}
+	}
+
+	var fixed []string
+	failed := 0
+	for _, member := range members {
+		held := GetCurrentQuizRoleLevel(cfg, member)
+		stale := held.Lower()
+		if len(stale) == 0 {
+			continue
+		}
+
+		var removed []string
+		for _, roleID := range stale {
+			if err := s.GuildMemberRoleRemove(guildID, member.User.ID, roleID); err != nil {
+				log.Printf("Gagal menghapus role %s dari %s: %v", roleID, member.User.ID, err)
+				logRoleError(s, cfg, member.User.ID, nil, "mencabut", roleID, err)
+				failed++
+				continue
+			}
+			removed = append(removed, roleID)
+		}
+		if len(removed) == 0 {
+			continue
+		}
+
+		now := time.Now()
+		reason := fmt.Sprintf("role level di bawah level %d dicabut", held.Level)
+		appendHistory(Attempt{
+			GuildID:      guildID,
+			UserID:       member.User.ID,
+			Level:        held.Level,
+			StartedAt:    now,
+			EndedAt:      now,
+			Outcome:      AttemptReconciled,
+			Reason:       reason,
+			RolesRemoved: removed,
+			ModeratorID:  moderatorID,
+		})
+		postModLog(s, cfg, modLogEvent{
+			Title:       "🧹 Role level ganda dirapikan",
+			Color:       modLogColorCleared,
+			UserID:      member.User.ID,
+			OldRoles:    removed,
+			NewRoles:    []string{held.RoleID},
+			ModeratorID: moderatorID,
+			Reason:      reason,
+		})
+		fixed = append(fixed, fmt.Sprintf("<@%s>: %d role dicabut, tetap <@&%s>", member.User.ID, len(removed), held.RoleID))
+	}
+
+	if len(fixed) == 0 && failed == 0 {
+		return fmt.Sprintf("%d member diperiksa, tidak ada yang memegang lebih dari satu role level.", len(members))
+	}
+	msg := fmt.Sprintf("%d member diperiksa, %d dirapikan:\n%s", len(members), len(fixed), strings.Join(fixed, "\n"))
+	if failed > 0 {
+		msg += fmt.Sprintf("\n%d role gagal dicabut, lihat log bot.", failed)
+	}
+	if len(msg) > 2000 { // batas pesan Discord
+		msg = fmt.Sprintf("%d member diperiksa, %d dirapikan. Daftar lengkap ada di /quiz history masing-masing member.", len(members), len(fixed))
+	}
+	return msg
+}
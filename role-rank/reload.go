@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// HandleReloadCommand memuat ulang katalog quiz dan konfigurasi guild
+// tanpa restart bot.
+// Sesi yang sedang berjalan tetap memakai definisi quiz lama.
+func HandleReloadCommand(s Discord, m *discordgo.MessageCreate) {
+	if strings.TrimSpace(m.Content) != "a!reload" {
+		return
+	}
+
+	cfg, ok := GuildConfigFor(m.GuildID)
+	if !ok {
+		return
+	}
+
+	member, err := s.GuildMember(m.GuildID, m.Author.ID)
+	if err != nil {
+		log.Printf("Gagal mendapatkan data member: %v", err)
+		return
+	}
+	if !cfg.IsModerator(member) {
+		s.ChannelMessageSend(m.ChannelID, "Kamu tidak punya izin untuk menggunakan perintah ini.")
+		return
+	}
+
+	s.ChannelMessageSend(m.ChannelID, reloadAndRepostSelectors(s, m.Author.ID))
+}
+
+// reloadAndRepostSelectors dipakai a!reload dan /quiz reload. Hasilnya pesan
+// untuk moderator.
+func reloadAndRepostSelectors(s Discord, moderatorID string) string {
+	catalog, err := ReloadQuizCatalog()
+	if err != nil {
+		log.Printf("Gagal reload katalog quiz: %v", err)
+		return fmt.Sprintf("Gagal reload katalog quiz, katalog lama tetap dipakai:\n```%v```", err)
+	}
+	log.Printf("Katalog quiz di-reload oleh %s: %d level", moderatorID, len(catalog.Quizzes))
+
+	// Kirim ulang selector supaya pilihan di dropdown ikut berubah
+	SendAllQuizSelectors(s)
+
+	return fmt.Sprintf("Katalog quiz berhasil di-reload (%d level). Quiz yang sedang berjalan tetap memakai pengaturan lama.", len(catalog.Quizzes))
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// reloadCatalogWith me-reload katalog dari salinan quizzes.json yang sudah
+// diubah edit. Katalog asli dipasang lagi setelah test selesai.
+func reloadCatalogWith(t *testing.T, edit func(file *catalogFile)) {
+	t.Helper()
+	data, err := os.ReadFile(quizCatalogPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var file catalogFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		t.Fatal(err)
+	}
+	edit(&file)
+	data, err = json.Marshal(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldPath := quizCatalogPath
+	quizCatalogPath = filepath.Join(t.TempDir(), "quizzes.json")
+	t.Cleanup(func() {
+		quizCatalogPath = oldPath
+		if _, err := ReloadQuizCatalog(); err != nil {
+			t.Errorf("restore catalog: %v", err)
+		}
+	})
+	if err := os.WriteFile(quizCatalogPath, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReloadQuizCatalog(); err != nil {
+		t.Fatalf("ReloadQuizCatalog: %v", err)
+	}
+}
+
+func setScoreLimit(file *catalogFile, quizID string, limit int) {
+	for n := range file.Quizzes {
+		if file.Quizzes[n].Value == quizID {
+			file.Quizzes[n].Stages[0].Command.ScoreLimit = limit
+		}
+	}
+}
+
+// TestReloadKeepsSessionPinned memastikan sesi yang sedang berjalan tetap
+// memakai definisi quiz saat sesi dibuat, bukan katalog hasil reload.
+func TestReloadKeepsSessionPinned(t *testing.T) {
+	f, cfg := setupFlowTest(t)
+	const userID = "100000000000000001"
+	f.addMember(userID)
+	user := &discordgo.User{ID: userID, Username: userID}
+	kotoba := &discordgo.User{ID: kotobaBotID, Username: "Kotoba", Bot: true}
+
+	selectQuiz(f, cfg, userID, "Level_1")
+	session, ok := sessions.Get(userID)
+	if !ok {
+		t.Fatalf("no session; reply %q", f.lastResponse())
+	}
+	stage := session.Quiz.Stages[0]
+	oldLimit := stage.ScoreLimit()
+	newLimit := oldLimit + 10
+
+	// Katalog baru dengan score limit Level_1 yang berbeda
+	reloadCatalogWith(t, func(file *catalogFile) { setScoreLimit(file, "Level_1", newLimit) })
+	if got := CurrentCatalog().Quizzes["Level_1"].Stages[0].ScoreLimit(); got != newLimit {
+		t.Fatalf("reloaded score limit = %d, want %d", got, newLimit)
+	}
+
+	// Command lama tetap diterima dan hanya score limit lama yang dihitung
+	sendMessage(f, session.ThreadID, user, stage.Command.String())
+	if got, _ := sessions.Get(userID); !got.Started {
+		t.Fatalf("old command not accepted after reload: %q", f.lastMessage(session.ThreadID))
+	}
+	sendMessage(f, session.ThreadID, kotoba, "", kotobaResultEmbed(stage.ExpectedDeck(), fmt.Sprint(newLimit), userID))
+	if got, ok := sessions.Get(userID); !ok || got.Progress != 0 {
+		t.Fatal("result with the reloaded score limit was accepted for the pinned session")
+	}
+
+	sendMessage(f, session.ThreadID, user, stage.Command.String())
+	sendMessage(f, session.ThreadID, kotoba, "", kotobaResultEmbed(stage.ExpectedDeck(), fmt.Sprint(oldLimit), userID))
+	if got := f.lastMessage(session.ThreadID); !strings.Contains(got, "**SELAMAT**") {
+		t.Fatalf("last message = %q", got)
+	}
+	// Tunggu channel ditutup supaya goroutine cleanup tidak membaca
+	// konfigurasi test berikutnya
+	if !eventually(t, func() bool { return !f.hasChannel(session.ThreadID) }) {
+		t.Error("quiz channel was not deleted")
+	}
+}
+
+// TestQueuedRequestUsesReloadedCatalog memastikan user yang menunggu di
+// antrian mendapat definisi quiz dari katalog saat gilirannya tiba.
+func TestQueuedRequestUsesReloadedCatalog(t *testing.T) {
+	f, cfg := setupFlowTest(t)
+	cfg.MaxOpenQuizzes = 1
+	const first, second = "100000000000000001", "100000000000000002"
+	f.addMember(first)
+	f.addMember(second)
+
+	selectQuiz(f, cfg, first, "Level_1")
+	active, ok := sessions.Get(first)
+	if !ok {
+		t.Fatalf("no session for first user; reply %q", f.lastResponse())
+	}
+	selectQuiz(f, cfg, second, "Level_1")
+	if got := f.lastResponse(); !strings.Contains(got, "antrian **#1**") {
+		t.Fatalf("second user response = %q, want queue position #1", got)
+	}
+
+	newLimit := active.Quiz.Stages[0].ScoreLimit() + 10
+	reloadCatalogWith(t, func(file *catalogFile) { setScoreLimit(file, "Level_1", newLimit) })
+
+	deleteQuizChannel(f, active.ThreadID)
+	if !eventually(t, func() bool { _, ok := sessions.Get(second); return ok }) {
+		t.Fatal("queued user was not provisioned after a slot opened")
+	}
+	provisioner.Wait()
+	session, _ := sessions.Get(second)
+	if got := session.Quiz.Stages[0].ScoreLimit(); got != newLimit {
+		t.Errorf("queued session score limit = %d, want reloaded %d", got, newLimit)
+	}
+	deleteQuizChannel(f, session.ThreadID)
+}
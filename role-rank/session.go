@@ -0,0 +1,277 @@
+package main
+
+import (
+	"log"
+	"slices"
+	"sync"
+)
+
+// SessionManager menyimpan semua sesi quiz aktif. Handler discordgo jalan
+// di goroutine terpisah, jadi semua akses ke sesi harus lewat sini.
+type SessionManager struct {
+	mu        sync.Mutex
+	sessions  map[string]QuizSession // userID -> QuizSession
+	byChannel map[string]string      // channelID -> userID
+	pending   map[string]struct{}    // userID yang channel-nya sedang dibuat
+	store     SessionStore
+	onDelete  func(session QuizSession)
+
+	// Store ditulis di luar mu supaya penulisan file tidak menahan handler
+	// lain. seq mengurutkan perubahan; persisted (dijaga saveMu) mencatat
+	// perubahan terakhir yang sudah ditulis per user, jadi perubahan lama
+	// yang kalah cepat tidak menimpa yang baru. unsaved (dijaga mu)
+	// menghitung perubahan per user yang belum selesai ditulis.
+	seq       uint64
+	unsaved   map[string]int
+	saveMu    sync.Mutex
+	persisted map[string]uint64
+}
+
+func NewSessionManager(store SessionStore) *SessionManager {
+	return &SessionManager{
+		sessions:  make(map[string]QuizSession),
+		byChannel: make(map[string]string),
+		pending:   make(map[string]struct{}),
+		store:     store,
+		unsaved:   make(map[string]int),
+		persisted: make(map[string]uint64),
+	}
+}
+
+// Get mengembalikan sesi milik user.
+func (m *SessionManager) Get(userID string) (QuizSession, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	session, ok := m.sessions[userID]
+	return session, ok
+}
+
+// GetByChannel mengembalikan sesi yang terikat ke channel quiz.
+func (m *SessionManager) GetByChannel(channelID string) (QuizSession, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	userID, ok := m.byChannel[channelID]
+	if !ok {
+		return QuizSession{}, false
+	}
+	return m.sessions[userID], true
+}
+
+// CountGuild mengembalikan jumlah sesi aktif di guild.
+func (m *SessionManager) CountGuild(guildID string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	count := 0
+	for _, session := range m.sessions {
+		if session.GuildID == guildID {
+			count++
+		}
+	}
+	return count
+}
+
+// OnDelete memasang fn yang dipanggil setiap kali sesi dihapus, di luar
+// kunci SessionManager.
+func (m *SessionManager) OnDelete(fn func(session QuizSession)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.onDelete = fn
+}
+
+// All mengembalikan salinan semua sesi aktif.
+func (m *SessionManager) All() []QuizSession {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	list := make([]QuizSession, 0, len(m.sessions))
+	for _, session := range m.sessions {
+		list = append(list, session)
+	}
+	return list
+}
+
+// Reserve menandai user sedang dibuatkan sesi. Hanya satu pemanggil yang
+// berhasil untuk user yang sama, jadi klik ganda di selector tidak
+// membuat dua channel. Panggil Create atau Release setelahnya.
+func (m *SessionManager) Reserve(userID string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.sessions[userID]; exists {
+		return false
+	}
+	if _, busy := m.pending[userID]; busy {
+		return false
+	}
+	m.pending[userID] = struct{}{}
+	return true
+}
+
+// Release membatalkan reservasi kalau pembuatan channel gagal.
+func (m *SessionManager) Release(userID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.pending, userID)
+}
+
+// Create menyimpan sesi baru dan melepas reservasinya.
+func (m *SessionManager) Create(session QuizSession) {
+	m.mu.Lock()
+	delete(m.pending, session.UserID)
+	m.put(session)
+	seq := m.next(session.UserID)
+	saved := session.clone()
+	m.mu.Unlock()
+
+	m.persist(session.UserID, seq, &saved)
+}
+
+// Update mengubah sesi secara atomik. fn mengembalikan false kalau tidak
+// ada perubahan; hasilnya sesi setelah diubah dan apakah fn menerapkannya.
+func (m *SessionManager) Update(userID string, fn func(session *QuizSession) bool) (QuizSession, bool) {
+	m.mu.Lock()
+	session, exists := m.sessions[userID]
+	if !exists || !fn(&session) {
+		m.mu.Unlock()
+		return session, false
+	}
+	m.put(session)
+	seq := m.next(userID)
+	saved := session.clone()
+	m.mu.Unlock()
+
+	m.persist(userID, seq, &saved)
+	return session, true
+}
+
+// Delete menghapus sesi milik user.
+func (m *SessionManager) Delete(userID string) (QuizSession, bool) {
+	m.mu.Lock()
+	session, ok := m.delete(userID)
+	var seq uint64
+	if ok {
+		seq = m.next(userID)
+	}
+	onDelete := m.onDelete
+	m.mu.Unlock()
+
+	if ok {
+		m.persist(userID, seq, nil)
+	}
+	if ok && onDelete != nil {
+		onDelete(session)
+	}
+	return session, ok
+}
+
+// DeleteByChannel menghapus sesi yang terikat ke channel quiz.
+func (m *SessionManager) DeleteByChannel(channelID string) (QuizSession, bool) {
+	m.mu.Lock()
+	userID, ok := m.byChannel[channelID]
+	var session QuizSession
+	if ok {
+		session, ok = m.delete(userID)
+	}
+	var seq uint64
+	if ok {
+		seq = m.next(userID)
+	}
+	onDelete := m.onDelete
+	m.mu.Unlock()
+
+	if ok {
+		m.persist(userID, seq, nil)
+	}
+	if ok && onDelete != nil {
+		onDelete(session)
+	}
+	return session, ok
+}
+
+// Restore memasukkan sesi hasil load dari store tanpa menulis ulang.
+func (m *SessionManager) Restore(session QuizSession) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.put(session)
+}
+
+// put menyimpan sesi ke kedua index. Dipanggil dengan mu terkunci.
+func (m *SessionManager) put(session QuizSession) {
+	if old, exists := m.sessions[session.UserID]; exists && old.ThreadID != session.ThreadID {
+		delete(m.byChannel, old.ThreadID)
+	}
+	m.sessions[session.UserID] = session
+	m.byChannel[session.ThreadID] = session.UserID
+}
+
+func (m *SessionManager) delete(userID string) (QuizSession, bool) {
+	session, exists := m.sessions[userID]
+	if !exists {
+		return QuizSession{}, false
+	}
+	delete(m.sessions, userID)
+	delete(m.byChannel, session.ThreadID)
+	return session, true
+}
+
+// next memberi nomor urut perubahan sesi user yang harus ditulis lewat
+// persist. Dipanggil dengan mu terkunci.
+func (m *SessionManager) next(userID string) uint64 {
+	m.seq++
+	m.unsaved[userID]++
+	return m.seq
+}
+
+// persist menulis perubahan ke store tanpa memegang mu. session nil
+// berarti sesi dihapus.
+func (m *SessionManager) persist(userID string, seq uint64, session *QuizSession) {
+	m.saveMu.Lock()
+	defer m.saveMu.Unlock()
+	defer m.written(userID)
+
+	if m.store == nil || m.persisted[userID] > seq {
+		return
+	}
+	m.persisted[userID] = seq
+	if session == nil {
+		if err := m.store.Delete(userID); err != nil {
+			log.Printf("Gagal menghapus sesi quiz %s: %v", userID, err)
+		}
+		return
+	}
+	if err := m.store.Save(*session); err != nil {
+		log.Printf("Gagal menyimpan sesi quiz %s: %v", userID, err)
+	}
+}
+
+// written menandai satu perubahan user selesai ditulis. Entri persisted
+// user yang dihapus tetap disimpan selama masih ada Save lama yang belum
+// ditulis, supaya Save itu tidak menghidupkan sesinya lagi di store;
+// setelah itu entrinya dibuang. Dipanggil dengan saveMu terkunci.
+func (m *SessionManager) written(userID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.unsaved[userID]--
+	if m.unsaved[userID] > 0 {
+		return
+	}
+	delete(m.unsaved, userID)
+	if _, exists := m.sessions[userID]; !exists {
+		delete(m.persisted, userID)
+	}
+}
+
+// clone menyalin slice sesi supaya salinan yang sedang ditulis ke store
+// tidak ikut berubah.
+func (s QuizSession) clone() QuizSession {
+	s.StageLog = slices.Clone(s.StageLog)
+	s.SettingsMismatch = slices.Clone(s.SettingsMismatch)
+	return s
+}
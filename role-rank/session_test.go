@@ -0,0 +1,295 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// memSessionStore adalah SessionStore di memori untuk test.
+type memSessionStore struct {
+	mu       sync.Mutex
+	sessions map[string]QuizSession
+}
+
+func newMemSessionStore() *memSessionStore {
+	return &memSessionStore{sessions: make(map[string]QuizSession)}
+}
+
+func (m *memSessionStore) Load() ([]QuizSession, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var list []QuizSession
+	for _, s := range m.sessions {
+		list = append(list, s)
+	}
+	return list, nil
+}
+
+func (m *memSessionStore) Save(session QuizSession) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sessions[session.UserID] = session
+	return nil
+}
+
+func (m *memSessionStore) Delete(userID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.sessions, userID)
+	return nil
+}
+
+func TestSessionManagerReserveOnce(t *testing.T) {
+	m := NewSessionManager(newMemSessionStore())
+
+	var wins atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if m.Reserve("user") {
+				wins.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := wins.Load(); got != 1 {
+		t.Fatalf("Reserve succeeded %d times, want 1", got)
+	}
+
+	// Sesi yang sudah dibuat juga menolak reservasi baru
+	m.Create(QuizSession{UserID: "user", ThreadID: "ch"})
+	if m.Reserve("user") {
+		t.Fatal("Reserve succeeded for user with an active session")
+	}
+
+	// Setelah Release user boleh reservasi lagi
+	m.Delete("user")
+	if !m.Reserve("user") {
+		t.Fatal("Reserve failed after session was deleted")
+	}
+	m.Release("user")
+	if !m.Reserve("user") {
+		t.Fatal("Reserve failed after Release")
+	}
+}
+
+func TestSessionManagerUpdateIsAtomic(t *testing.T) {
+	m := NewSessionManager(newMemSessionStore())
+	m.Create(QuizSession{UserID: "user", ThreadID: "ch", Started: true})
+
+	// Banyak embed Kotoba datang bersamaan, hanya satu yang boleh menutup tahap
+	var wins atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, ok := m.Update("user", func(s *QuizSession) bool {
+				if !s.Started {
+					return false
+				}
+				s.Started = false
+				s.Progress++
+				return true
+			})
+			if ok {
+				wins.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := wins.Load(); got != 1 {
+		t.Fatalf("Update applied %d times, want 1", got)
+	}
+	session, _ := m.Get("user")
+	if session.Progress != 1 {
+		t.Fatalf("Progress = %d, want 1", session.Progress)
+	}
+}
+
+func TestSessionManagerConcurrentLifecycle(t *testing.T) {
+	store := newMemSessionStore()
+	m := NewSessionManager(store)
+
+	const users = 50
+	var wg sync.WaitGroup
+	for i := 0; i < users; i++ {
+		userID := fmt.Sprintf("user-%d", i)
+		channelID := fmt.Sprintf("channel-%d", i)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if !m.Reserve(userID) {
+				t.Errorf("Reserve(%s) failed", userID)
+				return
+			}
+			m.Create(QuizSession{UserID: userID, ThreadID: channelID})
+
+			for n := 0; n < 20; n++ {
+				m.Update(userID, func(s *QuizSession) bool {
+					s.Started = !s.Started
+					return true
+				})
+				if s, ok := m.GetByChannel(channelID); !ok || s.UserID != userID {
+					t.Errorf("GetByChannel(%s) = %+v, %v", channelID, s, ok)
+					return
+				}
+			}
+
+			// Separuh dihapus lewat user, separuh lewat channel
+			if i%2 == 0 {
+				m.Delete(userID)
+			} else {
+				m.DeleteByChannel(channelID)
+			}
+		}()
+
+		// Pembaca lain ikut menyapu semua channel
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := 0; n < users; n++ {
+				m.GetByChannel(fmt.Sprintf("channel-%d", n))
+				m.Get(fmt.Sprintf("user-%d", n))
+			}
+		}()
+	}
+	wg.Wait()
+
+	for i := 0; i < users; i++ {
+		if _, ok := m.Get(fmt.Sprintf("user-%d", i)); ok {
+			t.Errorf("user-%d still has a session", i)
+		}
+	}
+	if saved, _ := store.Load(); len(saved) != 0 {
+		t.Errorf("store still holds %d sessions", len(saved))
+	}
+	// Catatan penulisan user yang sudah dihapus tidak menumpuk
+	if len(m.persisted) != 0 || len(m.unsaved) != 0 {
+		t.Errorf("persisted = %d users, unsaved = %d users after every session was deleted", len(m.persisted), len(m.unsaved))
+	}
+}
+
+func TestSessionManagerChannelIndex(t *testing.T) {
+	m := NewSessionManager(nil)
+	m.Create(QuizSession{UserID: "a", ThreadID: "ch-a"})
+	m.Create(QuizSession{UserID: "b", ThreadID: "ch-b"})
+
+	if s, ok := m.GetByChannel("ch-b"); !ok || s.UserID != "b" {
+		t.Fatalf("GetByChannel(ch-b) = %+v, %v", s, ok)
+	}
+
+	// Pindah channel: index lama harus ikut hilang
+	m.Update("a", func(s *QuizSession) bool {
+		s.ThreadID = "ch-a2"
+		return true
+	})
+	if _, ok := m.GetByChannel("ch-a"); ok {
+		t.Fatal("stale channel index for ch-a")
+	}
+	if s, ok := m.GetByChannel("ch-a2"); !ok || s.UserID != "a" {
+		t.Fatalf("GetByChannel(ch-a2) = %+v, %v", s, ok)
+	}
+
+	m.Delete("b")
+	if _, ok := m.GetByChannel("ch-b"); ok {
+		t.Fatal("channel index for ch-b survived Delete")
+	}
+	if s, ok := m.DeleteByChannel("ch-a2"); !ok || s.UserID != "a" {
+		t.Fatalf("DeleteByChannel(ch-a2) = %+v, %v", s, ok)
+	}
+	if _, ok := m.Get("a"); ok {
+		t.Fatal("session a survived DeleteByChannel")
+	}
+}
+
+// blockingSessionStore menahan Save sampai release ditutup.
+type blockingSessionStore struct {
+	*memSessionStore
+	saving  chan struct{}
+	release chan struct{}
+}
+
+func (b *blockingSessionStore) Save(session QuizSession) error {
+	b.saving <- struct{}{}
+	<-b.release
+	return b.memSessionStore.Save(session)
+}
+
+func TestSessionManagerSavesOutsideLock(t *testing.T) {
+	store := &blockingSessionStore{newMemSessionStore(), make(chan struct{}, 1), make(chan struct{})}
+	m := NewSessionManager(store)
+	m.Restore(QuizSession{UserID: "other", ThreadID: "ch-other"})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		m.Create(QuizSession{UserID: "user", ThreadID: "ch"})
+	}()
+	<-store.saving
+
+	// Penulisan store yang lambat tidak boleh menahan handler lain
+	lookup := make(chan bool)
+	go func() {
+		_, ok := m.GetByChannel("ch-other")
+		lookup <- ok
+	}()
+	select {
+	case ok := <-lookup:
+		if !ok {
+			t.Error("GetByChannel(ch-other) found nothing")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("GetByChannel blocked behind a store write")
+	}
+
+	close(store.release)
+	<-done
+	if saved, _ := store.Load(); len(saved) != 1 || saved[0].UserID != "user" {
+		t.Errorf("store = %+v, want the created session", saved)
+	}
+}
+
+func TestSessionManagerPersistsLatestState(t *testing.T) {
+	store := newMemSessionStore()
+	m := NewSessionManager(store)
+	m.Create(QuizSession{UserID: "user", ThreadID: "ch"})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			m.Update("user", func(s *QuizSession) bool {
+				s.Progress++
+				return true
+			})
+		}()
+	}
+	wg.Wait()
+
+	saved, _ := store.Load()
+	if len(saved) != 1 || saved[0].Progress != 50 {
+		t.Fatalf("store = %+v, want progress 50", saved)
+	}
+
+	if _, ok := m.persisted["user"]; !ok {
+		t.Error("persisted entry dropped while the session is active")
+	}
+
+	m.Delete("user")
+	if saved, _ := store.Load(); len(saved) != 0 {
+		t.Errorf("store still holds %+v after Delete", saved)
+	}
+	if _, ok := m.persisted["user"]; ok {
+		t.Error("persisted entry kept after the delete was written")
+	}
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// SessionStore menyimpan sesi quiz supaya tidak hilang saat bot restart.
+type SessionStore interface {
+	Load() ([]QuizSession, error)
+	Save(session QuizSession) error
+	Delete(userID string) error
+}
+
+// FileSessionStore menyimpan semua sesi dalam satu file JSON.
+// Setiap perubahan menulis ulang file lewat file sementara + rename
+// supaya file tidak korup kalau proses mati di tengah jalan.
+type FileSessionStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+func NewFileSessionStore(path string) *FileSessionStore {
+	return &FileSessionStore{path: path}
+}
+
+func (f *FileSessionStore) Load() ([]QuizSession, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	sessions, err := f.read()
+	if err != nil {
+		return nil, err
+	}
+	list := make([]QuizSession, 0, len(sessions))
+	for _, s := range sessions {
+		list = append(list, s)
+	}
+	return list, nil
+}
+
+func (f *FileSessionStore) Save(session QuizSession) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	sessions, err := f.read()
+	if err != nil {
+		return err
+	}
+	sessions[session.UserID] = session
+	return f.write(sessions)
+}
+
+func (f *FileSessionStore) Delete(userID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	sessions, err := f.read()
+	if err != nil {
+		return err
+	}
+	if _, ok := sessions[userID]; !ok {
+		return nil
+	}
+	delete(sessions, userID)
+	return f.write(sessions)
+}
+
+func (f *FileSessionStore) read() (map[string]QuizSession, error) {
+	sessions := make(map[string]QuizSession)
+
+	data, err := os.ReadFile(f.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return sessions, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("gagal membaca %s: %w", f.path, err)
+	}
+	if len(data) == 0 {
+		return sessions, nil
+	}
+	if err := json.Unmarshal(data, &sessions); err != nil {
+		return nil, fmt.Errorf("file sesi %s rusak: %w", f.path, err)
+	}
+	return sessions, nil
+}
+
+func (f *FileSessionStore) write(sessions map[string]QuizSession) error {
+	return writeJSONFile(f.path, sessions)
+}
+
+// writeJSONFile menulis v ke path lewat file sementara + rename.
+func writeJSONFile(path string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// RestoreSessions memuat sesi dari store setelah restart. Sesi yang
+// channel-nya sudah tidak ada (404 dari Discord) langsung dibuang.
+func RestoreSessions(s Discord, store SessionStore) {
+	saved, err := store.Load()
+	if err != nil {
+		log.Printf("Gagal memuat sesi quiz: %v", err)
+		return
+	}
+
+	restored := 0
+	for _, session := range saved {
+		if _, err := s.Channel(session.ThreadID); isUnknownChannel(err) {
+			log.Printf("Channel quiz %s milik user %s sudah tidak ada. Sesi dibuang.", session.ThreadID, session.UserID)
+			if err := store.Delete(session.UserID); err != nil {
+				log.Printf("Gagal menghapus sesi quiz %s: %v", session.UserID, err)
+			}
+			continue
+		} else if err != nil {
+			// Rate limit atau gangguan jaringan saat reconnect: sesi tetap
+			// dipulihkan, channel yang benar-benar hilang dibersihkan sweeper
+			log.Printf("Gagal mengecek channel quiz %s milik user %s, sesi tetap dipulihkan: %v", session.ThreadID, session.UserID, err)
+		}
+		// Sesi dari versi sebelum ada tahap memakai definisi quiz terbaru
+		if len(session.Quiz.Stages) == 0 {
+			quiz, ok := CurrentCatalog().Quizzes[session.QuizID]
+			if !ok || session.Progress >= len(quiz.Stages) {
+				log.Printf("Quiz %s milik user %s tidak dikenal lagi. Sesi dibuang.", session.QuizID, session.UserID)
+				if err := store.Delete(session.UserID); err != nil {
+					log.Printf("Gagal menghapus sesi quiz %s: %v", session.UserID, err)
+				}
+				continue
+			}
+			session.Quiz = quiz
+		}
+		sessions.Restore(session)
+		restored++
+	}
+	log.Printf("%d sesi quiz dipulihkan", restored)
+}
+
+// isUnknownChannel cek apakah err adalah respon Discord bahwa channel tidak
+// ada. Error lain (rate limit, 5xx, jaringan) tidak berarti channel hilang.
+func isUnknownChannel(err error) bool {
+	var restErr *discordgo.RESTError
+	if !errors.As(err, &restErr) {
+		return false
+	}
+	if restErr.Message != nil && restErr.Message.Code == discordgo.ErrCodeUnknownChannel {
+		return true
+	}
+	return restErr.Response != nil && restErr.Response.StatusCode == http.StatusNotFound
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestFileSessionStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sessions.json")
+	store := NewFileSessionStore(path)
+
+	if list, err := store.Load(); err != nil || len(list) != 0 {
+		t.Fatalf("Load on missing file = %v, %v", list, err)
+	}
+
+	catalog, err := LoadQuizCatalog("quizzes.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	quiz := catalog.Quizzes["Level_2"]
+	first := QuizSession{
+		GuildID:   testGuildID,
+		UserID:    "100000000000000001",
+		QuizID:    "Level_2",
+		Quiz:      quiz,
+		ThreadID:  "channel-1",
+		ChannelID: "selector",
+		Started:   true,
+		Progress:  1,
+		CreatedAt: time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC),
+		StageLog:  []StageAttempt{{Stage: 0, EndedAt: time.Date(2026, 10, 1, 12, 5, 0, 0, time.UTC), Passed: true}},
+	}
+	second := first
+	second.UserID, second.ThreadID = "100000000000000002", "channel-2"
+	third := first
+	third.UserID, third.ThreadID = "100000000000000003", "channel-3"
+	for _, session := range []QuizSession{first, second, third} {
+		if err := store.Save(session); err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+	}
+	if err := store.Delete(second.UserID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := store.Delete("not-saved"); err != nil {
+		t.Fatalf("Delete of unknown user: %v", err)
+	}
+
+	// Dibaca ulang lewat store baru seperti setelah restart
+	list, err := NewFileSessionStore(path).Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	sort.Slice(list, func(a, b int) bool { return list[a].UserID < list[b].UserID })
+	if want := []QuizSession{first, third}; !reflect.DeepEqual(list, want) {
+		t.Errorf("loaded sessions =\n%+v\nwant\n%+v", list, want)
+	}
+}
+
+// channelErrors membuat Channel gagal dengan error tertentu.
+type channelErrors struct {
+	*fakeGuild
+	errs map[string]error
+}
+
+func (c channelErrors) Channel(channelID string, options ...discordgo.RequestOption) (*discordgo.Channel, error) {
+	if err, ok := c.errs[channelID]; ok {
+		return nil, err
+	}
+	return c.fakeGuild.Channel(channelID, options...)
+}
+
+func TestRestoreSessions(t *testing.T) {
+	f, _ := setupFlowTest(t)
+	f.addChannel(&discordgo.Channel{ID: "channel-alive", Type: discordgo.ChannelTypeGuildText})
+	f.addChannel(&discordgo.Channel{ID: "channel-flaky", Type: discordgo.ChannelTypeGuildText})
+
+	store := NewFileSessionStore(filepath.Join(t.TempDir(), "sessions.json"))
+	quiz := CurrentCatalog().Quizzes["Level_1"]
+	for userID, channelID := range map[string]string{
+		"100000000000000001": "channel-alive",
+		"100000000000000002": "channel-gone",
+		"100000000000000003": "channel-flaky",
+	} {
+		err := store.Save(QuizSession{GuildID: testGuildID, UserID: userID, QuizID: "Level_1", Quiz: quiz, ThreadID: channelID})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s := channelErrors{f, map[string]error{"channel-flaky": errors.New("dial tcp: i/o timeout")}}
+	RestoreSessions(s, store)
+
+	for userID, want := range map[string]bool{
+		"100000000000000001": true,
+		"100000000000000002": false, // channel dihapus, 404
+		"100000000000000003": true,  // error jaringan, bukan bukti channel hilang
+	} {
+		if _, ok := sessions.Get(userID); ok != want {
+			t.Errorf("session %s restored = %v, want %v", userID, ok, want)
+		}
+	}
+	saved, _ := store.Load()
+	if len(saved) != 2 {
+		t.Errorf("store has %d sessions after restore, want 2", len(saved))
+	}
+}
+
+func TestIsUnknownChannel(t *testing.T) {
+	f := newFakeGuild(testGuildID)
+	_, notFound := f.Channel("missing")
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"unknown channel", notFound, true},
+		{"network", errors.New("connection reset"), false},
+		{"server error", &discordgo.RESTError{Response: &http.Response{StatusCode: http.StatusServiceUnavailable}}, false},
+		{"rate limit", &discordgo.RESTError{Response: &http.Response{StatusCode: http.StatusTooManyRequests}}, false},
+		{"wrapped", errors.Join(errors.New("restore"), notFound), true},
+	}
+	for _, tt := range tests {
+		if got := isUnknownChannel(tt.err); got != tt.want {
+			t.Errorf("%s: isUnknownChannel = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// createQuizThread membuat thread private di channel selector lalu
+// menambahkan user dan Kotoba. Thread tidak memakai slot channel guild dan
+// diarsipkan otomatis oleh Discord kalau tidak ada aktivitas.
+func createQuizThread(s Discord, cfg *GuildConfig, userID, name string) (*discordgo.Channel, error) {
+	thread, err := s.ThreadStartComplex(cfg.SelectorChannelID, &discordgo.ThreadStart{
+		Name:                name,
+		Type:                discordgo.ChannelTypeGuildPrivateThread,
+		AutoArchiveDuration: cfg.ThreadArchiveMinutes(),
+		Invitable:           false,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, memberID := range []string{userID, kotobaBotID} {
+		if err := s.ThreadMemberAdd(thread.ID, memberID); err != nil {
+			// Thread tanpa user atau Kotoba tidak bisa dipakai
+			if _, delErr := s.ChannelDelete(thread.ID); delErr != nil {
+				log.Printf("Gagal menghapus thread quiz %s: %v", thread.ID, delErr)
+			}
+			return nil, err
+		}
+	}
+	return thread, nil
+}
+
+// isQuizThread cek apakah channel adalah thread quiz guild ini.
+func isQuizThread(cfg *GuildConfig, channel *discordgo.Channel) bool {
+	return channel.Type == discordgo.ChannelTypeGuildPrivateThread && channel.ParentID == cfg.SelectorChannelID
+}
+
+// closeQuizChannel menutup tempat quiz: channel dihapus, thread diarsipkan
+// dan dikunci supaya riwayatnya tetap ada tapi user tidak bisa
+// membukanya lagi dengan mengirim pesan.
+func closeQuizChannel(s Discord, channelID string) error {
+	channel, err := s.Channel(channelID)
+	if err != nil {
+		return err
+	}
+	if !channel.IsThread() {
+		// Isi channel hilang setelah dihapus, simpan transkripnya dulu.
+		// Thread hanya diarsipkan jadi isinya tetap ada.
+		if err := archiveTranscript(s, channel); err != nil {
+			return fmt.Errorf("channel tidak dihapus karena transkrip gagal disimpan: %w", err)
+		}
+		_, err := s.ChannelDelete(channelID)
+		return err
+	}
+
+	archived, locked := true, true
+	_, err = s.ChannelEditComplex(channelID, &discordgo.ChannelEdit{Archived: &archived, Locked: &locked})
+	return err
+}
+
+// isArchivedThread cek apakah channel adalah thread yang sudah diarsipkan.
+func isArchivedThread(channel *discordgo.Channel) bool {
+	return channel.IsThread() && channel.ThreadMetadata != nil && channel.ThreadMetadata.Archived
+}
+
+// releaseArchivedThread membuang sesi milik thread yang sudah diarsipkan
+// lalu mengunci thread-nya supaya tidak terbuka lagi tanpa sesi.
+func releaseArchivedThread(s Discord, channel *discordgo.Channel) bool {
+	deleted, ok := sessions.DeleteByChannel(channel.ID)
+	if !ok {
+		return false
+	}
+	recordAttempt(deleted, AttemptAbandoned, "thread diarsipkan karena tidak aktif", nil, nil)
+
+	locked := true
+	if _, err := s.ChannelEditComplex(channel.ID, &discordgo.ChannelEdit{Locked: &locked}); err != nil {
+		log.Printf("Gagal mengunci thread quiz %s: %v", channel.ID, err)
+	}
+	log.Printf("Sesi quiz %s dibuang karena thread %s diarsipkan", deleted.UserID, channel.ID)
+	return true
+}
+
+// sweepArchivedQuizThreads membuang sesi yang thread-nya sudah diarsipkan
+// Discord karena tidak ada aktivitas. Sesi di channel biasa dilewati.
+func sweepArchivedQuizThreads(s Discord) {
+	for _, session := range sessions.All() {
+		if !session.InThread {
+			continue
+		}
+		channel, err := s.Channel(session.ThreadID)
+		if err != nil || !isArchivedThread(channel) {
+			continue
+		}
+		releaseArchivedThread(s, channel)
+	}
+}
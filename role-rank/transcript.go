@@ -0,0 +1,240 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html/template"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Batas Discord untuk satu request ChannelMessages
+const transcriptPageSize = 100
+
+// transcript adalah isi lengkap channel quiz sebelum dihapus.
+type transcript struct {
+	Channel     *discordgo.Channel
+	GeneratedAt time.Time
+	Messages    []*discordgo.Message // terlama duluan
+}
+
+// fetchTranscript membaca semua pesan di channel, halaman demi halaman.
+func fetchTranscript(s Discord, channel *discordgo.Channel) (*transcript, error) {
+	var msgs []*discordgo.Message
+	before := ""
+	for {
+		page, err := s.ChannelMessages(channel.ID, transcriptPageSize, before, "", "")
+		if err != nil {
+			return nil, fmt.Errorf("gagal membaca pesan channel %s: %w", channel.ID, err)
+		}
+		msgs = append(msgs, page...)
+		if len(page) < transcriptPageSize {
+			break
+		}
+		before = page[len(page)-1].ID
+	}
+
+	// Discord mengembalikan pesan terbaru lebih dulu
+	for l, r := 0, len(msgs)-1; l < r; l, r = l+1, r-1 {
+		msgs[l], msgs[r] = msgs[r], msgs[l]
+	}
+	return &transcript{Channel: channel, GeneratedAt: time.Now(), Messages: msgs}, nil
+}
+
+// Text merender transkrip sebagai teks biasa, satu pesan per blok.
+func (t *transcript) Text() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Transkrip #%s (%s)\n", t.Channel.Name, t.Channel.ID)
+	fmt.Fprintf(&b, "Dibuat %s, %d pesan\n", t.GeneratedAt.UTC().Format(time.RFC3339), len(t.Messages))
+
+	for _, msg := range t.Messages {
+		fmt.Fprintf(&b, "\n[%s] %s\n", msg.Timestamp.UTC().Format(time.RFC3339), authorName(msg.Author))
+		if msg.Content != "" {
+			fmt.Fprintln(&b, msg.Content)
+		}
+		for _, embed := range msg.Embeds {
+			fmt.Fprintln(&b, "  [embed]")
+			for _, line := range embedLines(embed) {
+				fmt.Fprintln(&b, "  "+strings.ReplaceAll(line, "\n", "\n  "))
+			}
+		}
+		for _, file := range msg.Attachments {
+			fmt.Fprintf(&b, "  [lampiran] %s %s\n", file.Filename, file.URL)
+		}
+	}
+	return b.String()
+}
+
+var transcriptHTML = template.Must(template.New("transcript").Funcs(template.FuncMap{
+	"author": authorName,
+	"time":   func(t time.Time) string { return t.UTC().Format(time.RFC3339) },
+	"color":  func(c int) string { return fmt.Sprintf("#%06x", c) },
+}).Parse(`<!DOCTYPE html>
+<html lang="id">
+<head>
+<meta charset="utf-8">
+<title>Transkrip #{{.Channel.Name}}</title>
+<style>
+body { font-family: sans-serif; background: #313338; color: #dbdee1; margin: 2em; }
+.msg { margin: 0.8em 0; }
+.author { font-weight: bold; color: #fff; }
+.time { color: #949ba4; font-size: 0.8em; margin-left: 0.5em; }
+.content { white-space: pre-wrap; }
+.embed { border-left: 4px solid #1e1f22; background: #2b2d31; padding: 0.5em 0.8em; margin: 0.3em 0; max-width: 40em; white-space: pre-wrap; }
+.embed .field { margin-top: 0.3em; }
+a { color: #00a8fc; }
+</style>
+</head>
+<body>
+<h1>#{{.Channel.Name}}</h1>
+<p>Channel {{.Channel.ID}} · dibuat {{time .GeneratedAt}} · {{len .Messages}} pesan</p>
+{{range .Messages}}<div class="msg">
+<span class="author">{{author .Author}}</span><span class="time">{{time .Timestamp}}</span>
+{{if .Content}}<div class="content">{{.Content}}</div>{{end}}
+{{range .Embeds}}<div class="embed"{{if .Color}} style="border-color: {{color .Color}}"{{end}}>
+{{if .Title}}<div><b>{{if .URL}}<a href="{{.URL}}">{{.Title}}</a>{{else}}{{.Title}}{{end}}</b></div>{{end}}
+{{if .Description}}<div>{{.Description}}</div>{{end}}
+{{range .Fields}}<div class="field"><b>{{.Name}}</b><br>{{.Value}}</div>{{end}}
+{{if .Footer}}<div class="time">{{.Footer.Text}}</div>{{end}}
+</div>{{end}}
+{{range .Attachments}}<div>📎 <a href="{{.URL}}">{{.Filename}}</a></div>{{end}}
+</div>
+{{end}}</body>
+</html>
+`))
+
+// HTML merender transkrip sebagai halaman HTML yang bisa dibuka di browser.
+func (t *transcript) HTML() ([]byte, error) {
+	var b bytes.Buffer
+	if err := transcriptHTML.Execute(&b, t); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+func authorName(user *discordgo.User) string {
+	if user == nil {
+		return "(tidak diketahui)"
+	}
+	return fmt.Sprintf("%s (%s)", user.Username, user.ID)
+}
+
+func embedLines(embed *discordgo.MessageEmbed) []string {
+	var lines []string
+	if embed.Title != "" {
+		lines = append(lines, embed.Title)
+	}
+	if embed.URL != "" {
+		lines = append(lines, embed.URL)
+	}
+	if embed.Description != "" {
+		lines = append(lines, embed.Description)
+	}
+	for _, field := range embed.Fields {
+		lines = append(lines, field.Name+": "+field.Value)
+	}
+	if embed.Footer != nil && embed.Footer.Text != "" {
+		lines = append(lines, embed.Footer.Text)
+	}
+	return lines
+}
+
+// archiveTranscript menyimpan isi channel quiz ke channel mod-log dan/atau
+// folder transkrip guild. Error hanya kalau semua tujuan yang dikonfigurasi
+// gagal, supaya channel tidak dihapus tanpa jejak.
+func archiveTranscript(s Discord, channel *discordgo.Channel) error {
+	cfg, ok := GuildConfigFor(channel.GuildID)
+	if !ok || (cfg.ModLogChannelID == "" && cfg.TranscriptDir == "") {
+		return nil
+	}
+
+	t, err := fetchTranscript(s, channel)
+	if err != nil {
+		return err
+	}
+	text := t.Text()
+	page, err := t.HTML()
+	if err != nil {
+		return fmt.Errorf("gagal merender transkrip %s: %w", channel.ID, err)
+	}
+	name := fmt.Sprintf("%s-%s", t.GeneratedAt.UTC().Format("20060102-150405"), channel.ID)
+
+	var errs []error
+	saved := false
+	if cfg.ModLogChannelID != "" {
+		_, err := s.ChannelMessageSendComplex(cfg.ModLogChannelID, &discordgo.MessageSend{
+			Content: fmt.Sprintf("📄 Transkrip channel quiz **%s** (`%s`), %d pesan.", channel.Name, channel.ID, len(t.Messages)),
+			Files: []*discordgo.File{
+				{Name: name + ".txt", ContentType: "text/plain; charset=utf-8", Reader: strings.NewReader(text)},
+				{Name: name + ".html", ContentType: "text/html; charset=utf-8", Reader: bytes.NewReader(page)},
+			},
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("gagal upload transkrip ke mod-log: %w", err))
+		} else {
+			saved = true
+		}
+	}
+	if cfg.TranscriptDir != "" {
+		if err := writeTranscript(filepath.Join(cfg.TranscriptDir, channel.GuildID), name, text, page); err != nil {
+			errs = append(errs, err)
+		} else {
+			saved = true
+		}
+	}
+
+	if saved {
+		for _, err := range errs {
+			log.Printf("Transkrip %s: %v", channel.ID, err)
+		}
+		return nil
+	}
+	return errors.Join(errs...)
+}
+
+func writeTranscript(dir, name, text string, page []byte) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("gagal membuat folder transkrip: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name+".txt"), []byte(text), 0o644); err != nil {
+		return fmt.Errorf("gagal menulis transkrip: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name+".html"), page, 0o644); err != nil {
+		return fmt.Errorf("gagal menulis transkrip: %w", err)
+	}
+	return nil
+}
+
+// pruneTranscripts menghapus transkrip di folder guild yang lebih tua dari
+// retensi guild.
+func pruneTranscripts(cfg *GuildConfig, guildID string) {
+	if cfg.TranscriptDir == "" || cfg.TranscriptRetention.Duration <= 0 {
+		return
+	}
+	dir := filepath.Join(cfg.TranscriptDir, guildID)
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return
+	}
+	if err != nil {
+		log.Printf("Gagal membaca folder transkrip %s: %v", dir, err)
+		return
+	}
+
+	threshold := time.Now().Add(-cfg.TranscriptRetention.Duration)
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || !entry.Type().IsRegular() || !info.ModTime().Before(threshold) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, entry.Name())); err != nil {
+			log.Printf("Gagal menghapus transkrip lama %s: %v", entry.Name(), err)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestQuizTranscript(t *testing.T) {
+	const userID = "100000000000000001"
+	f, cfg := setupFlowTest(t)
+	f.addMember(userID)
+	f.addChannel(&discordgo.Channel{ID: "mod-log", Type: discordgo.ChannelTypeGuildText})
+	cfg.ModLogChannelID = "mod-log"
+	cfg.TranscriptDir = t.TempDir()
+	cfg.TranscriptRetention = Duration{time.Hour}
+
+	selectQuiz(f, cfg, userID, "Level_1")
+	session, ok := sessions.Get(userID)
+	if !ok {
+		t.Fatalf("no session; reply %q", f.lastResponse())
+	}
+	user := &discordgo.User{ID: userID, Username: "murid"}
+	kotoba := &discordgo.User{ID: kotobaBotID, Username: "Kotoba", Bot: true}
+	post := func(msg *discordgo.Message) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.post(session.ThreadID, msg)
+	}
+	// Lebih dari satu halaman ChannelMessages
+	for n := range 150 {
+		post(&discordgo.Message{Author: user, Content: "jawaban <b>" + strings.Repeat("x", n%3) + "</b>"})
+	}
+	post(&discordgo.Message{Author: kotoba, Embeds: []*discordgo.MessageEmbed{kotobaResultEmbed("n5", "10", userID)}})
+
+	deleteQuizChannel(f, session.ThreadID)
+	if f.hasChannel(session.ThreadID) {
+		t.Fatal("quiz channel was not deleted")
+	}
+
+	f.mu.Lock()
+	files := f.files["mod-log"]
+	f.mu.Unlock()
+	if len(files) != 2 {
+		t.Fatalf("uploaded %d files to mod-log, want text and HTML", len(files))
+	}
+	text, _ := io.ReadAll(files[0].Reader)
+	page, _ := io.ReadAll(files[1].Reader)
+	if !strings.HasSuffix(files[0].Name, ".txt") || !strings.HasSuffix(files[1].Name, ".html") {
+		t.Errorf("file names = %s, %s", files[0].Name, files[1].Name)
+	}
+	for _, want := range []string{"Halo <@" + userID + ">", "murid (" + userID + ")", "n5 Ended", "The score limit of 10"} {
+		if !strings.Contains(string(text), want) {
+			t.Errorf("text transcript does not contain %q", want)
+		}
+	}
+	if n := strings.Count(string(text), "jawaban"); n != 150 {
+		t.Errorf("text transcript has %d user messages, want 150", n)
+	}
+	if strings.Index(string(text), "Halo <@") > strings.Index(string(text), "n5 Ended") {
+		t.Error("text transcript is not in chronological order")
+	}
+	if strings.Contains(string(page), "jawaban <b>") || !strings.Contains(string(page), "jawaban &lt;b&gt;") {
+		t.Error("HTML transcript does not escape message content")
+	}
+
+	// Salinan di disk dihapus setelah retensi lewat
+	dir := filepath.Join(cfg.TranscriptDir, testGuildID)
+	entries, err := os.ReadDir(dir)
+	if err != nil || len(entries) != 2 {
+		t.Fatalf("transcript dir has %d entries (%v), want 2", len(entries), err)
+	}
+	old := time.Now().Add(-2 * time.Hour)
+	if err := os.Chtimes(filepath.Join(dir, entries[0].Name()), old, old); err != nil {
+		t.Fatal(err)
+	}
+	sweepInactiveQuizChannels(f)
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("transcript dir has %d entries after sweep, want 1", len(entries))
+	}
+}
+
+func TestQuizTranscriptFailureKeepsChannel(t *testing.T) {
+	const userID = "100000000000000001"
+	f, cfg := setupFlowTest(t)
+	f.addMember(userID)
+	cfg.ModLogChannelID = "missing-channel"
+
+	selectQuiz(f, cfg, userID, "Level_1")
+	session, ok := sessions.Get(userID)
+	if !ok {
+		t.Fatalf("no session; reply %q", f.lastResponse())
+	}
+
+	deleteQuizChannel(f, session.ThreadID)
+	if !f.hasChannel(session.ThreadID) {
+		t.Error("quiz channel was deleted although its transcript could not be saved")
+	}
+}
@@ -8,12 +8,27 @@ import (
 	"github.com/bwmarrin/discordgo"
 )
 
-func SendQuizSelector(s *discordgo.Session, channelID string) {
+// SendAllQuizSelectors mengirim selector ke channel selector setiap guild
+// yang terhubung dan terdaftar di guilds.json. Setiap channel hanya
+// dikirimi sekali.
+func SendAllQuizSelectors(s Discord) {
+	sent := make(map[string]bool)
+	for _, guildID := range s.GuildIDs() {
+		cfg, ok := GuildConfigFor(guildID)
+		if !ok || sent[cfg.SelectorChannelID] {
+			continue
+		}
+		sent[cfg.SelectorChannelID] = true
+		SendQuizSelector(s, cfg.SelectorChannelID)
+	}
+}
+
+func SendQuizSelector(s Discord, channelID string) {
 	// Hapus semua pesan sebelumnya dari bot sendiri
 	messages, err := s.ChannelMessages(channelID, 100, "", "", "")
 	if err == nil {
 		for _, msg := range messages {
-			if msg.Author != nil && msg.Author.ID == s.State.User.ID {
+			if msg.Author != nil && msg.Author.ID == s.BotUser().ID {
 				_ = s.ChannelMessageDelete(channelID, msg.ID)
 				time.Sleep(200 * time.Millisecond) // Hindari rate limit
 			}
@@ -21,19 +36,9 @@ func SendQuizSelector(s *discordgo.Session, channelID string) {
 	}
 
 	var menuOptions []discordgo.SelectMenuOption
-	var quizOrder = []string{
-		"hiragana_katakana",
-		"Level_1",
-		"Level_2",
-		"Level_3",
-		"Level_4",
-		"Level_5",
-		"Level_6",
-		"Level_7",
-	}
-
-	for i, key := range quizOrder {
-		quiz, ok := Quizzes[key]
+	catalog := CurrentCatalog()
+	for i, key := range catalog.Order {
+		quiz, ok := catalog.Quizzes[key]
 		if !ok {
 			continue
 		}